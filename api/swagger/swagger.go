@@ -0,0 +1,8 @@
+package swagger
+
+import _ "embed"
+
+// UserSpec is the Swagger 2.0 document generated from api/user.proto
+//
+//go:embed api/user.swagger.json
+var UserSpec []byte
@@ -0,0 +1,599 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+
+	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/user"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/version"
+)
+
+// benchProgressInterval is how often bench reports progress
+const benchProgressInterval = 5 * time.Second
+
+// benchPercentiles are the latency percentiles reported
+var benchPercentiles = []float64{50, 90, 95, 99, 99.9}
+
+// benchOp is an operation of the load mix
+type benchOp struct {
+	name string // RPC name, used in reports
+	call func(b *bench, ctx context.Context) error
+}
+
+var benchOps = map[string]benchOp{
+	"create": {"CreateUser", (*bench).create},
+	"get":    {"GetUser", (*bench).get},
+	"list":   {"ListUsers", (*bench).list},
+	"search": {"SearchUsers", (*bench).search},
+}
+
+type benchFlags struct {
+	duration    time.Duration
+	warmup      time.Duration
+	rps         float64
+	concurrency int
+	connections int
+	mix         string
+	pageSize    int32
+	query       string
+	seed        int
+}
+
+func newBenchCommand(opts *options) *cobra.Command {
+	var flags benchFlags
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Generate load and report latency and throughput",
+		Long: `Generate load against the server and report throughput, latency
+percentiles and errors by status code, per RPC and overall.
+
+--mix weighs the operations create, get, list and search (or their RPC
+names), e.g. create=1,get=8,list=1. Without --rps, each of the --concurrency
+workers sends its next call as soon as the previous one completes; with --rps,
+calls are spread evenly at that total rate, using at most --concurrency
+calls in flight. Latency excludes --warmup and is measured per call from
+when it is sent or, with --rps, from when it was due, so time spent waiting
+for a free worker when the server falls behind the rate is counted.
+
+get picks IDs from the first page of users and the users created during the
+run; if there are none, --seed users are created first. Created users have
+emails of the form bench-<run>-<n>@example.com and are not removed.
+
+--output json or yaml prints the report in a machine-readable form for
+comparing runs. An interrupt stops the run early and reports what was
+measured.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBench(cmd, opts, flags)
+		},
+	}
+	cmd.Flags().DurationVarP(&flags.duration, "duration", "d", 30*time.Second, "How long to generate load, excluding the warmup")
+	cmd.Flags().DurationVar(&flags.warmup, "warmup", 0, "Load generated before measuring starts")
+	cmd.Flags().Float64Var(&flags.rps, "rps", 0, "Target calls per second (default as fast as --concurrency allows)")
+	cmd.Flags().IntVarP(&flags.concurrency, "concurrency", "c", 10, "Maximum number of calls in flight")
+	cmd.Flags().IntVar(&flags.connections, "connections", 1, "Number of connections the calls are spread over")
+	cmd.Flags().StringVar(&flags.mix, "mix", "create=1,get=8,list=1", "Weighted operations: create, get, list, search")
+	cmd.Flags().Int32Var(&flags.pageSize, "page-size", 0, "Page size of list and search calls (default server default)")
+	cmd.Flags().StringVar(&flags.query, "query", "bench", "Query of search calls")
+	cmd.Flags().IntVar(&flags.seed, "seed", 100, "Users created before the run when get has none to pick from")
+	return cmd
+}
+
+// benchMix picks operations by weight
+type benchMix struct {
+	ops     []string
+	weights []int
+	total   int
+}
+
+// parseMix parses a list of op=weight pairs
+func parseMix(s string) (*benchMix, error) {
+	mix := &benchMix{}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, weight, ok := strings.Cut(part, "=")
+		if !ok {
+			weight = "1"
+		}
+		op, found := "", false
+		for key, o := range benchOps {
+			if strings.EqualFold(name, key) || strings.EqualFold(name, o.name) {
+				op, found = key, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown operation %q in --mix, expected create, get, list or search", name)
+		}
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid weight %q for %s in --mix", weight, name)
+		}
+		if seen[op] {
+			return nil, fmt.Errorf("%s appears more than once in --mix", name)
+		}
+		seen[op] = true
+		if w > 0 {
+			mix.ops = append(mix.ops, op)
+			mix.weights = append(mix.weights, w)
+			mix.total += w
+		}
+	}
+	if mix.total == 0 {
+		return nil, fmt.Errorf("--mix selects no operations")
+	}
+	return mix, nil
+}
+
+func (m *benchMix) pick(r *mathrand.Rand) string {
+	n := r.Intn(m.total)
+	for i, w := range m.weights {
+		if n < w {
+			return m.ops[i]
+		}
+		n -= w
+	}
+	return m.ops[len(m.ops)-1]
+}
+
+func (m *benchMix) weight(op string) int {
+	for i, o := range m.ops {
+		if o == op {
+			return m.weights[i]
+		}
+	}
+	return 0
+}
+
+// bench is the state shared by the workers of a run
+type bench struct {
+	opts    *options
+	flags   benchFlags
+	clients []pb.UserServiceClient
+	run     string
+	created atomic.Int64
+
+	mu  sync.RWMutex
+	ids []string
+}
+
+func (b *bench) client() pb.UserServiceClient {
+	return b.clients[mathrand.Intn(len(b.clients))]
+}
+
+func (b *bench) create(ctx context.Context) error {
+	n := b.created.Add(1)
+	user, err := b.client().CreateUser(ctx, &pb.CreateUserRequest{
+		Name:  fmt.Sprintf("Bench User %d", n),
+		Email: fmt.Sprintf("bench-%s-%d@example.com", b.run, n),
+	})
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.ids = append(b.ids, user.Id)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *bench) get(ctx context.Context) error {
+	b.mu.RLock()
+	id := b.ids[mathrand.Intn(len(b.ids))]
+	b.mu.RUnlock()
+	_, err := b.client().GetUser(ctx, &pb.GetUserRequest{Id: id})
+	return err
+}
+
+func (b *bench) list(ctx context.Context) error {
+	_, err := b.client().ListUsers(ctx, &pb.ListUsersRequest{PageSize: b.flags.pageSize})
+	return err
+}
+
+func (b *bench) search(ctx context.Context) error {
+	_, err := b.client().SearchUsers(ctx, &pb.SearchUsersRequest{Query: b.flags.query, PageSize: b.flags.pageSize})
+	return err
+}
+
+// prepare loads the IDs get picks from, seeding users if there are none
+func (b *bench) prepare(ctx context.Context) error {
+	callCtx, cancel := callContext(ctx, b.opts)
+	resp, err := b.client().ListUsers(callCtx, &pb.ListUsersRequest{PageSize: 1000})
+	cancel()
+	if err != nil {
+		return err
+	}
+	for _, u := range resp.Users {
+		b.ids = append(b.ids, u.Id)
+	}
+
+	if len(b.ids) > 0 {
+		return nil
+	}
+	if b.flags.seed < 1 {
+		return fmt.Errorf("there are no users to get; use --seed or remove get from --mix")
+	}
+	for i := 0; i < b.flags.seed; i++ {
+		callCtx, cancel := callContext(ctx, b.opts)
+		err := b.create(callCtx)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// benchSample is the outcome of one call
+type benchSample struct {
+	op      string
+	latency time.Duration
+	code    codes.Code
+}
+
+// benchRecorder collects the samples of one worker
+type benchRecorder struct {
+	samples []benchSample
+}
+
+func runBench(cmd *cobra.Command, opts *options, flags benchFlags) error {
+	if flags.duration <= 0 || flags.warmup < 0 || flags.rps < 0 {
+		return usageError{fmt.Errorf("--duration must be positive, --warmup and --rps not negative")}
+	}
+	if flags.concurrency < 1 || flags.connections < 1 {
+		return usageError{fmt.Errorf("--concurrency and --connections must be at least 1")}
+	}
+	format := strings.ToLower(opts.output)
+	if format != formatTable && format != formatJSON && format != formatYAML {
+		return usageError{fmt.Errorf("bench prints table, json or yaml output")}
+	}
+	mix, err := parseMix(flags.mix)
+	if err != nil {
+		return usageError{err}
+	}
+
+	runID := make([]byte, 4)
+	rand.Read(runID)
+	b := &bench{opts: opts, flags: flags, run: hex.EncodeToString(runID)}
+	for i := 0; i < flags.connections; i++ {
+		// Separate connections rather than the shell's shared one, which
+		// would also print the latency of every call
+		conn, err := newConn(opts)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		b.clients = append(b.clients, pb.NewUserServiceClient(conn))
+	}
+
+	ctx := cmd.Context()
+	if mix.weight("get") > 0 {
+		if err := b.prepare(ctx); err != nil {
+			return err
+		}
+	}
+
+	stderr := cmd.ErrOrStderr()
+	fmt.Fprintf(stderr, "Running %s against %s (%s)\n", flags.duration, opts.server, describeLoad(flags))
+
+	start := time.Now()
+	measureFrom := start.Add(flags.warmup)
+	end := measureFrom.Add(flags.duration)
+	var interval time.Duration
+	if flags.rps > 0 {
+		interval = time.Duration(float64(time.Second) / flags.rps)
+	}
+
+	var next, done atomic.Int64
+	var errorCount atomic.Int64
+	recorders := make([]*benchRecorder, flags.concurrency)
+	var wg sync.WaitGroup
+	for w := range recorders {
+		rec := &benchRecorder{}
+		recorders[w] = rec
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := mathrand.New(mathrand.NewSource(seed))
+			for {
+				var due time.Time
+				if interval > 0 {
+					// Call i is due at start + i*interval
+					due = start.Add(time.Duration(next.Add(1)-1) * interval)
+					if !due.Before(end) {
+						return
+					}
+					select {
+					case <-time.After(time.Until(due)):
+					case <-ctx.Done():
+						return
+					}
+				}
+				now := time.Now()
+				if !now.Before(end) || ctx.Err() != nil {
+					return
+				}
+				sent := now
+				if interval > 0 {
+					sent = due
+				}
+
+				op := mix.pick(r)
+				callCtx, cancel := callContext(ctx, opts)
+				err := benchOps[op].call(b, callCtx)
+				latency := time.Since(sent)
+				cancel()
+				if ctx.Err() != nil {
+					// Calls cut short by an interrupt are not measured
+					return
+				}
+				if sent.Before(measureFrom) {
+					continue
+				}
+				rec.samples = append(rec.samples, benchSample{op: op, latency: latency, code: status.Code(err)})
+				done.Add(1)
+				if err != nil {
+					errorCount.Add(1)
+				}
+			}
+		}(time.Now().UnixNano() + int64(w))
+	}
+
+	stopProgress := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(benchProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				elapsed := time.Since(measureFrom)
+				if elapsed <= 0 {
+					fmt.Fprintf(stderr, "Warming up\n")
+					continue
+				}
+				fmt.Fprintf(stderr, "%s: %d calls, %.1f/s, %d errors\n",
+					elapsed.Round(time.Second), done.Load(), float64(done.Load())/elapsed.Seconds(), errorCount.Load())
+			case <-stopProgress:
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(stopProgress)
+
+	elapsed := time.Since(measureFrom)
+	if elapsed > flags.duration {
+		elapsed = flags.duration
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	var samples []benchSample
+	for _, rec := range recorders {
+		samples = append(samples, rec.samples...)
+	}
+	report := newBenchReport(opts, flags, mix, measureFrom, elapsed, samples)
+	report.Interrupted = ctx.Err() != nil
+
+	out := cmd.OutOrStdout()
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case formatYAML:
+		var data interface{}
+		raw, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(out)
+		enc.SetIndent(2)
+		if err := enc.Encode(data); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return report.print(out)
+	}
+}
+
+func describeLoad(flags benchFlags) string {
+	load := fmt.Sprintf("concurrency %d", flags.concurrency)
+	if flags.rps > 0 {
+		load = fmt.Sprintf("%g calls/s, at most %d in flight", flags.rps, flags.concurrency)
+	}
+	if flags.warmup > 0 {
+		load += fmt.Sprintf(", after %s warmup", flags.warmup)
+	}
+	return load + ", mix " + flags.mix
+}
+
+// benchReport is the result of a run. Its JSON form is meant to be
+// compared across builds, so fields are only ever added.
+type benchReport struct {
+	ClientVersion string        `json:"client_version"`
+	Server        string        `json:"server"`
+	Started       time.Time     `json:"started"`
+	Interrupted   bool          `json:"interrupted"`
+	Config        benchConfig   `json:"config"`
+	Total         benchStats    `json:"total"`
+	Operations    []benchStats  `json:"operations"`
+	Duration      time.Duration `json:"-"`
+}
+
+type benchConfig struct {
+	DurationSeconds float64        `json:"duration_seconds"`
+	WarmupSeconds   float64        `json:"warmup_seconds"`
+	TargetRPS       float64        `json:"target_rps"`
+	Concurrency     int            `json:"concurrency"`
+	Connections     int            `json:"connections"`
+	Mix             map[string]int `json:"mix"`
+}
+
+// benchStats summarizes the calls of one operation, or of all of them
+type benchStats struct {
+	Operation  string         `json:"operation"`
+	Calls      int            `json:"calls"`
+	Errors     int            `json:"errors"`
+	Throughput float64        `json:"throughput_rps"`
+	Latency    benchLatency   `json:"latency_ms"`
+	Codes      map[string]int `json:"codes"`
+}
+
+type benchLatency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	P999 float64 `json:"p99_9"`
+	Max  float64 `json:"max"`
+}
+
+func newBenchReport(opts *options, flags benchFlags, mix *benchMix, started time.Time, elapsed time.Duration, samples []benchSample) *benchReport {
+	report := &benchReport{
+		ClientVersion: version.Version,
+		Server:        opts.server,
+		Started:       started.UTC(),
+		Duration:      elapsed,
+		Config: benchConfig{
+			DurationSeconds: flags.duration.Seconds(),
+			WarmupSeconds:   flags.warmup.Seconds(),
+			TargetRPS:       flags.rps,
+			Concurrency:     flags.concurrency,
+			Connections:     flags.connections,
+			Mix:             make(map[string]int),
+		},
+	}
+
+	byOp := make(map[string][]benchSample)
+	for _, s := range samples {
+		byOp[s.op] = append(byOp[s.op], s)
+	}
+	ops := append([]string{}, mix.ops...)
+	sort.Strings(ops)
+	for _, op := range ops {
+		report.Config.Mix[benchOps[op].name] = mix.weight(op)
+		report.Operations = append(report.Operations, summarize(benchOps[op].name, byOp[op], elapsed))
+	}
+	report.Total = summarize("total", samples, elapsed)
+	return report
+}
+
+func summarize(name string, samples []benchSample, elapsed time.Duration) benchStats {
+	stats := benchStats{Operation: name, Calls: len(samples), Codes: make(map[string]int)}
+	if len(samples) == 0 {
+		return stats
+	}
+
+	latencies := make([]time.Duration, len(samples))
+	var sum time.Duration
+	for i, s := range samples {
+		latencies[i] = s.latency
+		sum += s.latency
+		stats.Codes[s.code.String()]++
+		if s.code != codes.OK {
+			stats.Errors++
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	if elapsed > 0 {
+		stats.Throughput = float64(len(samples)) / elapsed.Seconds()
+	}
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	percentile := func(p float64) float64 {
+		i := int(float64(len(latencies))*p/100+0.999999) - 1
+		if i < 0 {
+			i = 0
+		}
+		return ms(latencies[i])
+	}
+	stats.Latency = benchLatency{
+		Min:  ms(latencies[0]),
+		Mean: ms(sum / time.Duration(len(latencies))),
+		P50:  percentile(benchPercentiles[0]),
+		P90:  percentile(benchPercentiles[1]),
+		P95:  percentile(benchPercentiles[2]),
+		P99:  percentile(benchPercentiles[3]),
+		P999: percentile(benchPercentiles[4]),
+		Max:  ms(latencies[len(latencies)-1]),
+	}
+	return stats
+}
+
+// print writes the report as tables
+func (r *benchReport) print(w io.Writer) error {
+	if r.Interrupted {
+		fmt.Fprintln(w, "Interrupted; partial results")
+	}
+	rate := fmt.Sprintf("%.1f calls/s", r.Total.Throughput)
+	if r.Config.TargetRPS > 0 {
+		rate += fmt.Sprintf(" of %g targeted", r.Config.TargetRPS)
+	}
+	fmt.Fprintf(w, "%d calls in %s, %s, %d errors\n\n",
+		r.Total.Calls, r.Duration.Round(time.Millisecond), rate, r.Total.Errors)
+
+	// Numbers are right-aligned; padding the names keeps them left-aligned
+	rows := append(r.Operations, r.Total)
+	width := len("OPERATION")
+	for _, s := range rows {
+		if len(s.Operation) > width {
+			width = len(s.Operation)
+		}
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "%-*s\tCALLS\tRPS\tERRORS\tMIN\tMEAN\tP50\tP90\tP95\tP99\tP99.9\tMAX\t\n", width, "OPERATION")
+	for _, s := range rows {
+		l := s.Latency
+		fmt.Fprintf(tw, "%-*s\t%d\t%.1f\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t\n",
+			width, s.Operation, s.Calls, s.Throughput, s.Errors, l.Min, l.Mean, l.P50, l.P90, l.P95, l.P99, l.P999, l.Max)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "\nLatencies in milliseconds.")
+
+	if r.Total.Errors == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "\nErrors by status code:")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range r.Operations {
+		names := make([]string, 0, len(s.Codes))
+		for code := range s.Codes {
+			if code != codes.OK.String() {
+				names = append(names, code)
+			}
+		}
+		sort.Strings(names)
+		for _, code := range names {
+			fmt.Fprintf(tw, "  %s\t%s\t%d\n", s.Operation, code, s.Codes[code])
+		}
+	}
+	return tw.Flush()
+}
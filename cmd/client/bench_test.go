@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestParseMix(t *testing.T) {
+	tests := []struct {
+		name    string
+		mix     string
+		ops     []string
+		weights []int
+		wantErr string
+	}{
+		{"default", "create=1,get=8,list=1", []string{"create", "get", "list"}, []int{1, 8, 1}, ""},
+		{"rpc names ignore case", "CreateUser=2,searchusers=3", []string{"create", "search"}, []int{2, 3}, ""},
+		{"weight defaults to one", "get, list=4", []string{"get", "list"}, []int{1, 4}, ""},
+		{"zero weight drops op", "create=0,get=1", []string{"get"}, []int{1}, ""},
+		{"empty parts skipped", " ,get=2,, ", []string{"get"}, []int{2}, ""},
+		{"unknown op", "get=1,delete=1", nil, nil, "unknown operation"},
+		{"negative weight", "get=-1", nil, nil, "invalid weight"},
+		{"non-numeric weight", "get=many", nil, nil, "invalid weight"},
+		{"duplicate", "get=1,GetUser=2", nil, nil, "more than once"},
+		{"nothing selected", "get=0", nil, nil, "no operations"},
+		{"empty", "", nil, nil, "no operations"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mix, err := parseMix(tt.mix)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			// Order follows --mix, not the map of operations
+			assert.Equal(t, tt.ops, mix.ops)
+			assert.Equal(t, tt.weights, mix.weights)
+			total := 0
+			for _, w := range tt.weights {
+				total += w
+			}
+			assert.Equal(t, total, mix.total)
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	// Latencies of 1ms to 100ms make percentile p the p-th millisecond
+	hundred := make([]benchSample, 100)
+	for i := range hundred {
+		hundred[i] = benchSample{op: "get", latency: time.Duration(100-i) * time.Millisecond, code: codes.OK}
+	}
+	hundred[10].code = codes.NotFound
+	hundred[20].code = codes.Unavailable
+	hundred[30].code = codes.Unavailable
+
+	tests := []struct {
+		name    string
+		samples []benchSample
+		elapsed time.Duration
+		want    benchStats
+	}{
+		{"no samples", nil, 10 * time.Second, benchStats{Operation: "op", Codes: map[string]int{}}},
+		{"single sample", []benchSample{{latency: 3 * time.Millisecond}}, 2 * time.Second, benchStats{
+			Operation:  "op",
+			Calls:      1,
+			Throughput: 0.5,
+			Latency:    benchLatency{Min: 3, Mean: 3, P50: 3, P90: 3, P95: 3, P99: 3, P999: 3, Max: 3},
+			Codes:      map[string]int{"OK": 1},
+		}},
+		{"percentiles and codes", hundred, 10 * time.Second, benchStats{
+			Operation:  "op",
+			Calls:      100,
+			Errors:     3,
+			Throughput: 10,
+			Latency:    benchLatency{Min: 1, Mean: 50.5, P50: 50, P90: 90, P95: 95, P99: 99, P999: 100, Max: 100},
+			Codes:      map[string]int{"OK": 97, "NotFound": 1, "Unavailable": 2},
+		}},
+		{"no elapsed time", hundred[:1], 0, benchStats{
+			Operation: "op",
+			Calls:     1,
+			Latency:   benchLatency{Min: 100, Mean: 100, P50: 100, P90: 100, P95: 100, P99: 100, P999: 100, Max: 100},
+			Codes:     map[string]int{"OK": 1},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, summarize("op", tt.samples, tt.elapsed))
+		})
+	}
+}
@@ -0,0 +1,580 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/user"
+)
+
+// File formats of import and export
+const (
+	fileCSV   = "csv"
+	fileJSONL = "jsonl"
+)
+
+// exportPageSize is the page size export requests, the server's maximum
+const exportPageSize = 1000
+
+// progressInterval is how often import reports progress
+const progressInterval = 2 * time.Second
+
+// fileFormat returns the explicit format, or the one implied by the file
+// extension, falling back to fallback
+func fileFormat(format, path, fallback string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			format = fileCSV
+		case ".jsonl", ".ndjson":
+			format = fileJSONL
+		default:
+			format = fallback
+		}
+	}
+	switch format = strings.ToLower(format); format {
+	case fileCSV, fileJSONL:
+		return format, nil
+	case "":
+		return "", usageError{fmt.Errorf("cannot tell the format of %s, use --format csv or --format jsonl", path)}
+	default:
+		return "", usageError{fmt.Errorf("unknown file format %q, expected csv or jsonl", format)}
+	}
+}
+
+// importRow is a user read from an import file. err is set for rows that
+// could not be parsed.
+type importRow struct {
+	line  int
+	name  string
+	email string
+	err   error
+}
+
+// rowReader reads import rows until io.EOF
+type rowReader interface {
+	next() (importRow, error)
+}
+
+// csvRows reads CSV with a header row. Only the name and email columns are
+// used, so exported files can be imported again.
+type csvRows struct {
+	r           *csv.Reader
+	name, email int
+}
+
+func newCSVRows(r io.Reader) (*csvRows, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+
+	rows := &csvRows{r: cr, name: -1, email: -1}
+	for i, h := range header {
+		switch normalizeColumn(h) {
+		case "name":
+			rows.name = i
+		case "email":
+			rows.email = i
+		}
+	}
+	if rows.name < 0 || rows.email < 0 {
+		return nil, fmt.Errorf("CSV header must have name and email columns")
+	}
+	return rows, nil
+}
+
+func (c *csvRows) next() (importRow, error) {
+	record, err := c.r.Read()
+	if errors.Is(err, io.EOF) {
+		return importRow{}, err
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return importRow{line: parseErr.StartLine, err: parseErr.Err}, nil
+		}
+		return importRow{}, err
+	}
+
+	line, _ := c.r.FieldPos(0)
+	row := importRow{line: line}
+	if c.name < len(record) {
+		row.name = record[c.name]
+	}
+	if c.email < len(record) {
+		row.email = record[c.email]
+	}
+	return row, nil
+}
+
+// jsonlRows reads one JSON user object per line, skipping blank lines.
+// Fields other than name and email are ignored.
+type jsonlRows struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func newJSONLRows(r io.Reader) *jsonlRows {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	return &jsonlRows{s: s}
+}
+
+func (j *jsonlRows) next() (importRow, error) {
+	for j.s.Scan() {
+		j.line++
+		text := strings.TrimSpace(j.s.Text())
+		if text == "" {
+			continue
+		}
+
+		var req pb.CreateUserRequest
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal([]byte(text), &req); err != nil {
+			return importRow{line: j.line, err: err}, nil
+		}
+		return importRow{line: j.line, name: req.Name, email: req.Email}, nil
+	}
+	if err := j.s.Err(); err != nil {
+		return importRow{}, err
+	}
+	return importRow{}, io.EOF
+}
+
+// checkpoint records how many rows of a file have been processed, so an
+// interrupted import can resume after them
+type checkpoint struct {
+	Source string `json:"source"`
+	Rows   int    `json:"rows"`
+}
+
+func loadCheckpoint(path, source string) (int, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return 0, fmt.Errorf("invalid checkpoint file %s: %w", path, err)
+	}
+	if cp.Source != source {
+		return 0, fmt.Errorf("checkpoint file %s belongs to %s", path, cp.Source)
+	}
+	return cp.Rows, nil
+}
+
+// saveCheckpoint replaces the checkpoint atomically, so an interrupt never
+// leaves a truncated file behind
+func saveCheckpoint(path, source string, rows int) error {
+	b, err := json.Marshal(checkpoint{Source: source, Rows: rows})
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// errorReport writes failed rows as CSV, or to stderr without a report file
+type errorReport struct {
+	csv    *csv.Writer
+	stderr io.Writer
+}
+
+func (r *errorReport) add(row importRow, err error) {
+	message := errorMessage(err)
+	if r.csv == nil {
+		fmt.Fprintf(r.stderr, "line %d: %s\n", row.line, message)
+		return
+	}
+	code := "Local"
+	if st, ok := status.FromError(err); ok {
+		code = st.Code().String()
+	}
+	r.csv.Write([]string{fmt.Sprint(row.line), row.name, row.email, code, message})
+}
+
+// importStats counts the outcome of processed rows
+type importStats struct {
+	rows    int
+	created int
+	failed  int
+}
+
+type importFlags struct {
+	format      string
+	concurrency int
+	batchSize   int
+	dryRun      bool
+	checkpoint  string
+	errors      string
+}
+
+func newImportCommand(opts *options) *cobra.Command {
+	var flags importFlags
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Create users from a CSV or JSONL file",
+		Long: `Create users from a CSV or JSONL file ("-" reads stdin).
+
+CSV files need a header with name and email columns; JSONL files hold one
+object with name and email fields per line. Other columns and fields are
+ignored, so files written by export can be imported.
+
+Rows are sent in batches of --batch-size with up to --concurrency calls in
+flight. An interrupt stops the import once the current batch has finished.
+With --checkpoint, the number of finished rows is recorded after every batch,
+and a later run with the same checkpoint resumes after them; the file is
+removed once the import completes. Failed rows are written to --errors as CSV
+(line, name, email, code, error) or to stderr.
+
+--dry-run validates the file without contacting the server.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport(cmd, opts, flags, args[0])
+		},
+	}
+	cmd.Flags().StringVar(&flags.format, "format", "", "File format: csv or jsonl (default from the file extension)")
+	cmd.Flags().IntVar(&flags.concurrency, "concurrency", 8, "Maximum number of concurrent calls")
+	cmd.Flags().IntVar(&flags.batchSize, "batch-size", 100, "Rows per batch; the checkpoint is updated after each batch")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Validate the file without creating users")
+	cmd.Flags().StringVar(&flags.checkpoint, "checkpoint", "", "Checkpoint file used to resume an interrupted import")
+	cmd.Flags().StringVar(&flags.errors, "errors", "", "CSV file for the rows that failed (default stderr)")
+	cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{fileCSV, fileJSONL}, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+func runImport(cmd *cobra.Command, opts *options, flags importFlags, path string) error {
+	if flags.concurrency < 1 || flags.batchSize < 1 {
+		return usageError{fmt.Errorf("--concurrency and --batch-size must be at least 1")}
+	}
+	if path == "-" && flags.checkpoint != "" {
+		return usageError{fmt.Errorf("--checkpoint cannot be used with stdin")}
+	}
+	format, err := fileFormat(flags.format, path, "")
+	if err != nil {
+		return err
+	}
+
+	in := cmd.InOrStdin()
+	source := "-"
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+		if source, err = filepath.Abs(path); err != nil {
+			return err
+		}
+	}
+
+	var rows rowReader
+	if format == fileCSV {
+		if rows, err = newCSVRows(in); err != nil {
+			return err
+		}
+	} else {
+		rows = newJSONLRows(in)
+	}
+
+	skip := 0
+	if flags.checkpoint != "" {
+		if skip, err = loadCheckpoint(flags.checkpoint, source); err != nil {
+			return err
+		}
+	}
+
+	report := &errorReport{stderr: cmd.ErrOrStderr()}
+	if flags.errors != "" {
+		// A resumed import adds to the rows that failed before it stopped
+		flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if skip > 0 {
+			flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		f, err := os.OpenFile(flags.errors, flag, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		report.csv = csv.NewWriter(f)
+		if info.Size() == 0 {
+			report.csv.Write([]string{"line", "name", "email", "code", "error"})
+		}
+		defer report.csv.Flush()
+	}
+
+	// Rows are validated as they are read, so duplicates are reported
+	// against their first occurrence regardless of concurrency
+	validate := newRowValidator()
+	create := func(ctx context.Context, row importRow) error { return nil }
+	if !flags.dryRun {
+		client, closeConn, err := dial(cmd.Context(), opts)
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+		create = func(ctx context.Context, row importRow) error {
+			ctx, cancel := callContext(ctx, opts)
+			defer cancel()
+			_, err := client.CreateUser(ctx, &pb.CreateUserRequest{Name: row.name, Email: row.email})
+			return err
+		}
+	}
+
+	start := time.Now()
+	stats := importStats{rows: skip}
+	if skip > 0 {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Resuming after %d rows\n", skip)
+	}
+	lastProgress := start
+	ctx := cmd.Context()
+	for read, done := 0, false; !done; {
+		var batch []importRow
+		var invalid int
+		for n := 0; n < flags.batchSize; {
+			row, err := rows.next()
+			if errors.Is(err, io.EOF) {
+				done = true
+				break
+			}
+			if err != nil {
+				return err
+			}
+			verr := validate(row)
+			if read++; read <= skip {
+				continue
+			}
+			n++
+			if verr != nil {
+				report.add(row, verr)
+				invalid++
+				continue
+			}
+			batch = append(batch, row)
+		}
+
+		// An interrupt lets the batch finish so the checkpoint stays exact
+		failures := runBatch(context.WithoutCancel(ctx), batch, flags.concurrency, create)
+		for i, err := range failures {
+			if err != nil {
+				report.add(batch[i], err)
+				stats.failed++
+			} else {
+				stats.created++
+			}
+		}
+		stats.failed += invalid
+		stats.rows += len(batch) + invalid
+
+		if flags.checkpoint != "" && len(batch)+invalid > 0 {
+			if err := saveCheckpoint(flags.checkpoint, source, stats.rows); err != nil {
+				return fmt.Errorf("saving checkpoint: %w", err)
+			}
+		}
+		if ctx.Err() != nil {
+			if flags.checkpoint != "" {
+				return fmt.Errorf("interrupted after %d rows; run again with the same --checkpoint to resume", stats.rows)
+			}
+			return fmt.Errorf("interrupted after %d rows", stats.rows)
+		}
+		if !done && time.Since(lastProgress) >= progressInterval {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Processed %d rows: %d created, %d failed\n", stats.rows, stats.created, stats.failed)
+			lastProgress = time.Now()
+		}
+	}
+
+	if flags.checkpoint != "" {
+		if err := os.Remove(flags.checkpoint); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	verb := "created"
+	if flags.dryRun {
+		verb = "valid"
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Processed %d rows in %s: %d %s, %d failed\n",
+		stats.rows, time.Since(start).Round(time.Millisecond), stats.created, verb, stats.failed)
+	if stats.failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", stats.failed, stats.rows)
+	}
+	return nil
+}
+
+// newRowValidator returns a check applying the server's validation locally
+// and rejecting emails repeated within the file
+func newRowValidator() func(row importRow) error {
+	seen := make(map[string]int)
+	return func(row importRow) error {
+		if row.err != nil {
+			return row.err
+		}
+		var problems []string
+		if strings.TrimSpace(row.name) == "" {
+			problems = append(problems, "name is required")
+		}
+		if strings.TrimSpace(row.email) == "" {
+			problems = append(problems, "email is required")
+		}
+		if len(problems) > 0 {
+			return errors.New(strings.Join(problems, "; "))
+		}
+
+		key := strings.ToLower(strings.TrimSpace(row.email))
+		if line, ok := seen[key]; ok {
+			return fmt.Errorf("email address already used on line %d", line)
+		}
+		seen[key] = row.line
+		return nil
+	}
+}
+
+// runBatch calls fn for every row with at most concurrency calls in flight,
+// returning the error of each row
+func runBatch(ctx context.Context, batch []importRow, concurrency int, fn func(ctx context.Context, row importRow) error) []error {
+	errs := make([]error, len(batch))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range batch {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = fn(ctx, batch[i])
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+func newExportCommand(opts *options) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "export [FILE]",
+		Short: "Write every user to a CSV or JSONL file",
+		Long: `Write every user to a CSV or JSONL file, or to stdout without FILE or
+with "-". The format follows the file extension unless --format is given, and
+defaults to CSV.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "-"
+			if len(args) > 0 {
+				path = args[0]
+			}
+			format, err := fileFormat(format, path, fileCSV)
+			if err != nil {
+				return err
+			}
+			return runExport(cmd, opts, format, path)
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "", "File format: csv or jsonl (default from the file extension)")
+	cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{fileCSV, fileJSONL}, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+func runExport(cmd *cobra.Command, opts *options, format, path string) (err error) {
+	client, closeConn, err := dial(cmd.Context(), opts)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	w := cmd.OutOrStdout()
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+
+	var cw *csv.Writer
+	if format == fileCSV {
+		cw = csv.NewWriter(bw)
+		cw.Write(columnNamesOf(userColumns))
+	}
+
+	count := 0
+	token := ""
+	for {
+		ctx, cancel := callContext(cmd.Context(), opts)
+		resp, err := client.ListUsers(ctx, &pb.ListUsersRequest{PageSize: exportPageSize, PageToken: token})
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		for _, user := range resp.Users {
+			if cw != nil {
+				row := make([]string, len(userColumns))
+				for i, c := range userColumns {
+					row[i] = c.value(user, false)
+				}
+				cw.Write(row)
+				continue
+			}
+			b, err := jsonOptions.Marshal(user)
+			if err != nil {
+				return err
+			}
+			bw.Write(append(b, '\n'))
+		}
+		count += len(resp.Users)
+
+		if token = resp.NextPageToken; token == "" {
+			break
+		}
+	}
+
+	if cw != nil {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if path != "-" {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d users to %s\n", count, path)
+	}
+	return nil
+}
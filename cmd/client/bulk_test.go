@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		path     string
+		fallback string
+		want     string
+		wantErr  string
+	}{
+		{"csv extension", "", "users.csv", "", fileCSV, ""},
+		{"jsonl extension", "", "users.JSONL", "", fileJSONL, ""},
+		{"ndjson extension", "", "users.ndjson", "", fileJSONL, ""},
+		{"explicit format wins", "jsonl", "users.csv", "", fileJSONL, ""},
+		{"explicit format ignores case", "CSV", "-", "", fileCSV, ""},
+		{"fallback", "", "-", fileCSV, fileCSV, ""},
+		{"unknown extension", "", "users.txt", "", "", "cannot tell the format"},
+		{"unknown format", "xml", "users.csv", "", "", "unknown file format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fileFormat(tt.format, tt.path, tt.fallback)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				var usage usageError
+				assert.True(t, errors.As(err, &usage), "not a usage error")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// wantRow is an expected import row; invalid marks rows that failed to parse
+type wantRow struct {
+	line        int
+	name, email string
+	invalid     bool
+}
+
+// readRows reads every row of r
+func readRows(t *testing.T, r rowReader) []wantRow {
+	t.Helper()
+	var rows []wantRow
+	for {
+		row, err := r.next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		require.NoError(t, err)
+		rows = append(rows, wantRow{line: row.line, name: row.name, email: row.email, invalid: row.err != nil})
+	}
+}
+
+func TestCSVRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		rows    []wantRow
+		wantErr string
+	}{
+		{"name and email", "name,email\nAda,ada@example.com\nBob,bob@example.com\n", []wantRow{
+			{line: 2, name: "Ada", email: "ada@example.com"},
+			{line: 3, name: "Bob", email: "bob@example.com"},
+		}, ""},
+		{"export columns", "ID,Email,Name,Created_At\n1,ada@example.com,Ada,2024-01-01\n", []wantRow{
+			{line: 2, name: "Ada", email: "ada@example.com"},
+		}, ""},
+		{"quoted fields", "name,email\n\"Lovelace, Ada\",ada@example.com\n", []wantRow{
+			{line: 2, name: "Lovelace, Ada", email: "ada@example.com"},
+		}, ""},
+		{"short record", "email,name\nada@example.com\n", []wantRow{
+			{line: 2, email: "ada@example.com"},
+		}, ""},
+		{"malformed row is reported", "name,email\nA\"da,ada@example.com\nBob,bob@example.com\n", []wantRow{
+			{line: 2, invalid: true},
+			{line: 3, name: "Bob", email: "bob@example.com"},
+		}, ""},
+		{"header only", "name,email\n", nil, ""},
+		{"missing column", "name,mail\nAda,ada@example.com\n", nil, "name and email columns"},
+		{"empty file", "", nil, "reading CSV header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := newCSVRows(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.rows, readRows(t, rows))
+		})
+	}
+}
+
+func TestJSONLRows(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		rows  []wantRow
+	}{
+		{"one object per line", "{\"name\":\"Ada\",\"email\":\"ada@example.com\"}\n{\"name\":\"Bob\",\"email\":\"bob@example.com\"}", []wantRow{
+			{line: 1, name: "Ada", email: "ada@example.com"},
+			{line: 2, name: "Bob", email: "bob@example.com"},
+		}},
+		{"blank lines skipped", "\n  \n{\"name\":\"Ada\",\"email\":\"ada@example.com\"}\n\n", []wantRow{
+			{line: 3, name: "Ada", email: "ada@example.com"},
+		}},
+		{"exported fields ignored", `{"id":"1","name":"Ada","email":"ada@example.com","createdAt":"2024-01-01T00:00:00Z"}`, []wantRow{
+			{line: 1, name: "Ada", email: "ada@example.com"},
+		}},
+		{"invalid line is reported", "{\"name\":\"Ada\"\n{\"name\":\"Bob\",\"email\":\"bob@example.com\"}\n", []wantRow{
+			{line: 1, invalid: true},
+			{line: 2, name: "Bob", email: "bob@example.com"},
+		}},
+		{"wrong type is reported", `{"name":42}`, []wantRow{{line: 1, invalid: true}}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.rows, readRows(t, newJSONLRows(strings.NewReader(tt.input))))
+		})
+	}
+}
+
+func TestRowValidator(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    []importRow
+		wantErr []string
+	}{
+		{"valid rows", []importRow{
+			{line: 2, name: "Ada", email: "ada@example.com"},
+			{line: 3, name: "Bob", email: "bob@example.com"},
+		}, []string{"", ""}},
+		{"missing fields", []importRow{
+			{line: 2, name: " ", email: "ada@example.com"},
+			{line: 3, name: "Bob"},
+			{line: 4},
+		}, []string{"name is required", "email is required", "name is required; email is required"}},
+		{"parse error", []importRow{
+			{line: 2, err: errors.New("bare \" in non-quoted field")},
+		}, []string{"bare \" in non-quoted field"}},
+		{"duplicate email ignores case and spaces", []importRow{
+			{line: 2, name: "Ada", email: "ada@example.com"},
+			{line: 3, name: "Ada", email: " ADA@example.com "},
+			{line: 4, name: "Ada", email: "ada@example.com"},
+		}, []string{"", "email address already used on line 2", "email address already used on line 2"}},
+		{"invalid row does not claim its email", []importRow{
+			{line: 2, email: "ada@example.com"},
+			{line: 3, name: "Ada", email: "ada@example.com"},
+		}, []string{"name is required", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validate := newRowValidator()
+			for i, row := range tt.rows {
+				err := validate(row)
+				if tt.wantErr[i] == "" {
+					assert.NoError(t, err, "row %d", i)
+				} else {
+					assert.EqualError(t, err, tt.wantErr[i], "row %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	tests := []struct {
+		name string
+		// content is written to the checkpoint file unless empty
+		content string
+		source  string
+		want    int
+		wantErr string
+	}{
+		{"no checkpoint", "", "/data/users.csv", 0, ""},
+		{"same source", `{"source":"/data/users.csv","rows":300}`, "/data/users.csv", 300, ""},
+		{"other source", `{"source":"/data/other.csv","rows":300}`, "/data/users.csv", 0, "belongs to /data/other.csv"},
+		{"invalid file", `{"rows":`, "/data/users.csv", 0, "invalid checkpoint file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "import.checkpoint")
+			if tt.content != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+			}
+			got, err := loadCheckpoint(path, tt.source)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "import.checkpoint")
+		require.NoError(t, saveCheckpoint(path, "/data/users.csv", 100))
+		require.NoError(t, saveCheckpoint(path, "/data/users.csv", 200))
+		rows, err := loadCheckpoint(path, "/data/users.csv")
+		require.NoError(t, err)
+		assert.Equal(t, 200, rows)
+		_, err = os.Stat(path + ".tmp")
+		assert.ErrorIs(t, err, os.ErrNotExist, "temporary file left behind")
+	})
+}
+
+func TestImportErrorReport(t *testing.T) {
+	const input = "name,email\n" +
+		"Ada,ada@example.com\n" +
+		",bob@example.com\n" +
+		"Cy,ada@example.com\n" +
+		"Di,\n"
+	const (
+		header = "line,name,email,code,error\n"
+		line3  = "3,,bob@example.com,Local,name is required\n"
+		line4  = "4,Cy,ada@example.com,Local,email address already used on line 2\n"
+		line5  = "5,Di,,Local,email is required\n"
+	)
+
+	tests := []struct {
+		name string
+		// report is the content of the error report before the import, if any
+		report string
+		// resume is the number of rows recorded in the checkpoint, if any
+		resume  int
+		want    string
+		wantErr string
+	}{
+		{"new report", "", 0, header + line3 + line4 + line5, "3 of 4 rows failed"},
+		{"fresh import replaces the report", header + "9,Old,old@example.com,Internal,failed\n", 0,
+			header + line3 + line4 + line5, "3 of 4 rows failed"},
+		{"resumed import appends", header + line3, 2, header + line3 + line4 + line5, "2 of 4 rows failed"},
+		{"resumed import writes the header once", "", 2, header + line4 + line5, "2 of 4 rows failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "users.csv")
+			require.NoError(t, os.WriteFile(path, []byte(input), 0o644))
+			report := filepath.Join(dir, "errors.csv")
+			if tt.report != "" {
+				require.NoError(t, os.WriteFile(report, []byte(tt.report), 0o644))
+			}
+			checkpointFile := filepath.Join(dir, "import.checkpoint")
+			if tt.resume > 0 {
+				require.NoError(t, saveCheckpoint(checkpointFile, path, tt.resume))
+			}
+
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			cmd.SetErr(io.Discard)
+			flags := importFlags{concurrency: 2, batchSize: 2, dryRun: true, checkpoint: checkpointFile, errors: report}
+			err := runImport(cmd, &options{}, flags, path)
+			require.Error(t, err)
+			assert.Equal(t, tt.wantErr, err.Error())
+
+			got, err := os.ReadFile(report)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+			_, err = os.Stat(checkpointFile)
+			assert.ErrorIs(t, err, os.ErrNotExist, "checkpoint kept after the import completed")
+		})
+	}
+}
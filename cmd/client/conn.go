@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/user"
+)
+
+// newTLSConfig builds the client TLS configuration, or nil when TLS is disabled
+func newTLSConfig(opts *options) (*tls.Config, error) {
+	if !opts.useTLS {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		ServerName:         opts.tlsServerName,
+		InsecureSkipVerify: opts.tlsInsecureSkipVerify,
+	}
+	if opts.tlsCA != "" {
+		pem, err := os.ReadFile(opts.tlsCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.tlsCA)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// connCacheKey is the context key of a connCache
+type connCacheKey struct{}
+
+// connCache keeps one connection open across the commands of a shell
+// session, replacing it when the connection settings change
+type connCache struct {
+	key  string
+	conn *grpc.ClientConn
+
+	// dialOptions are added to every connection, e.g. for call latency
+	dialOptions []grpc.DialOption
+}
+
+func withConnCache(ctx context.Context, cache *connCache) context.Context {
+	return context.WithValue(ctx, connCacheKey{}, cache)
+}
+
+// Close closes the cached connection
+func (c *connCache) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn, c.key = nil, ""
+	return err
+}
+
+// dial creates a client for the user service. The connection is
+// established lazily, so failures surface as errors of the first RPC. In a
+// shell session the connection is shared and the returned close is a no-op.
+func dial(ctx context.Context, opts *options) (pb.UserServiceClient, func() error, error) {
+	cache, _ := ctx.Value(connCacheKey{}).(*connCache)
+	key := fmt.Sprintf("%q %t %q %q %t %q %q", opts.server, opts.useTLS, opts.tlsCA, opts.tlsServerName,
+		opts.tlsInsecureSkipVerify, opts.token, opts.apiKey)
+	if cache != nil && cache.conn != nil && cache.key == key {
+		return pb.NewUserServiceClient(cache.conn), func() error { return nil }, nil
+	}
+
+	var extra []grpc.DialOption
+	if cache != nil {
+		extra = cache.dialOptions
+	}
+	conn, err := newConn(opts, extra...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cache == nil {
+		return pb.NewUserServiceClient(conn), conn.Close, nil
+	}
+
+	cache.Close()
+	cache.key, cache.conn = key, conn
+	return pb.NewUserServiceClient(conn), func() error { return nil }, nil
+}
+
+// newConn creates a connection with the TLS settings and credentials of
+// opts, followed by extra
+func newConn(opts *options, extra ...grpc.DialOption) (*grpc.ClientConn, error) {
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	var pairs []string
+	if opts.token != "" {
+		pairs = append(pairs, "authorization", "Bearer "+opts.token)
+	}
+	if opts.apiKey != "" {
+		pairs = append(pairs, "x-api-key", opts.apiKey)
+	}
+
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if len(pairs) > 0 {
+		dialOpts = append(dialOpts,
+			grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				return invoker(metadata.AppendToOutgoingContext(ctx, pairs...), method, req, reply, cc, opts...)
+			}),
+			grpc.WithStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return streamer(metadata.AppendToOutgoingContext(ctx, pairs...), desc, cc, method, opts...)
+			}),
+		)
+	}
+
+	dialOpts = append(dialOpts, extra...)
+	conn, err := grpc.NewClient(opts.server, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	return conn, nil
+}
+
+// callContext bounds a single call by the --timeout flag
+func callContext(ctx context.Context, opts *options) (context.Context, context.CancelFunc) {
+	if opts.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, opts.timeout)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Exit codes, so scripts can branch on the kind of failure
+const (
+	exitOK            = 0
+	exitError         = 1   // any other error, e.g. Internal or a local failure
+	exitUsage         = 2   // invalid command line
+	exitInvalid       = 3   // InvalidArgument, OutOfRange, FailedPrecondition
+	exitNotFound      = 4   // NotFound
+	exitConflict      = 5   // AlreadyExists, Aborted
+	exitDenied        = 6   // Unauthenticated, PermissionDenied
+	exitUnavailable   = 7   // Unavailable
+	exitTimeout       = 8   // DeadlineExceeded
+	exitExhausted     = 9   // ResourceExhausted
+	exitUnimplemented = 10  // Unimplemented
+	exitInterrupted   = 130 // interrupted by a signal
+)
+
+// exitCodes maps gRPC status codes to exit codes
+var exitCodes = map[codes.Code]int{
+	codes.OK:                 exitOK,
+	codes.InvalidArgument:    exitInvalid,
+	codes.OutOfRange:         exitInvalid,
+	codes.FailedPrecondition: exitInvalid,
+	codes.NotFound:           exitNotFound,
+	codes.AlreadyExists:      exitConflict,
+	codes.Aborted:            exitConflict,
+	codes.Unauthenticated:    exitDenied,
+	codes.PermissionDenied:   exitDenied,
+	codes.Unavailable:        exitUnavailable,
+	codes.DeadlineExceeded:   exitTimeout,
+	codes.ResourceExhausted:  exitExhausted,
+	codes.Unimplemented:      exitUnimplemented,
+}
+
+// usageError marks errors in the command line itself
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+
+func (e usageError) Unwrap() error { return e.err }
+
+// exitCode returns the process exit code for an error returned by a command.
+// ctx is the command context, which is cancelled by an interrupt.
+func exitCode(ctx context.Context, err error) int {
+	if err == nil {
+		return exitOK
+	}
+	if errors.As(err, new(usageError)) {
+		return exitUsage
+	}
+	if ctx.Err() != nil {
+		return exitInterrupted
+	}
+	if st, ok := status.FromError(err); ok {
+		if code, ok := exitCodes[st.Code()]; ok {
+			return code
+		}
+	}
+	return exitError
+}
+
+// errorMessage describes err for the terminal, naming the status code of
+// server errors instead of the "rpc error" prefix
+func errorMessage(err error) string {
+	if st, ok := status.FromError(err); ok {
+		return fmt.Sprintf("%s: %s", st.Code(), st.Message())
+	}
+	return err.Error()
+}
@@ -2,70 +2,167 @@ package main
 
 import (
 	"context"
-	"flag"
-	"log"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
-	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/user"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/version"
 )
 
+// options holds the global flags shared by every command
+type options struct {
+	server                string
+	useTLS                bool
+	tlsCA                 string
+	tlsServerName         string
+	tlsInsecureSkipVerify bool
+	token                 string
+	apiKey                string
+	timeout               time.Duration
+	profile               string
+	configFile            string
+	output                string
+	columns               string
+	template              string
+
+	// outputSet records whether the output format was chosen explicitly
+	outputSet bool
+	// explicit holds the global flags given on the command line, before
+	// the environment and profile are applied
+	explicit map[string]string
+	// started is set once the command line has been parsed and validated
+	started bool
+}
+
 func main() {
-	// Command-line flags
-	serverAddr := flag.String("server", "localhost:50051", "The server address in the format of host:port")
-	createUser := flag.Bool("create", false, "Create a new user")
-	getUserID := flag.String("get", "", "Get user by ID")
-	userName := flag.String("name", "", "User name for create operation")
-	userEmail := flag.String("email", "", "User email for create operation")
-	flag.Parse()
-
-	// Set up connection to server
-	conn, err := grpc.Dial(*serverAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		log.Fatalf("Failed to connect: %v", err)
-	}
-	defer conn.Close()
-
-	client := pb.NewUserServiceClient(conn)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Create a new user
-	if *createUser {
-		if *userName == "" || *userEmail == "" {
-			log.Fatal("Name and email are required for user creation")
-		}
-
-		resp, err := client.CreateUser(ctx, &pb.CreateUserRequest{
-			Name:  *userName,
-			Email: *userEmail,
-		})
-		if err != nil {
-			log.Fatalf("Failed to create user: %v", err)
-		}
-
-		log.Printf("User created successfully with ID: %s", resp.Id)
-		log.Printf("User details: Name=%s, Email=%s", resp.Name, resp.Email)
+	// Interrupts cancel in-flight calls and end watches
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	root, opts := newRootCommand()
+	if err := execute(ctx, root, opts); err != nil {
+		code := exitCode(ctx, err)
+		stop()
+		os.Exit(code)
 	}
+}
 
-	// Get user by ID
-	if *getUserID != "" {
-		resp, err := client.GetUser(ctx, &pb.GetUserRequest{
-			Id: *getUserID,
-		})
-		if err != nil {
-			log.Fatalf("Failed to get user: %v", err)
-		}
+// execute runs the command line of root and prints any error. Errors in the
+// command line itself are returned as usage errors.
+func execute(ctx context.Context, root *cobra.Command, opts *options) error {
+	cmd, err := root.ExecuteContextC(ctx)
+	if err == nil {
+		return nil
+	}
+	if !opts.started {
+		err = usageError{err}
+	}
 
-		log.Printf("User found: ID=%s, Name=%s, Email=%s", resp.Id, resp.Name, resp.Email)
+	fmt.Fprintf(root.ErrOrStderr(), "Error: %s\n", errorMessage(err))
+	if errors.As(err, new(usageError)) {
+		fmt.Fprintf(root.ErrOrStderr(), "Run '%s --help' for usage.\n", cmd.CommandPath())
 	}
+	return err
+}
+
+func newRootCommand() (*cobra.Command, *options) {
+	opts := &options{}
 
-	// If no operation was specified
-	if !*createUser && *getUserID == "" {
-		log.Println("No operation specified. Use --create to create a user or --get=<id> to get a user.")
-		log.Println("Example usage:")
-		log.Println("  ./client --create --name=\"John Doe\" --email=\"john@example.com\"")
-		log.Println("  ./client --get=<user_id>")
+	root := &cobra.Command{
+		Use:   "client",
+		Short: "Command-line client for the user service",
+		Long: `Command-line client for the user service.
+
+Connection settings come from flags, then USER_CLIENT_* environment variables
+(e.g. USER_CLIENT_SERVER, USER_CLIENT_TOKEN), then the selected profile of the
+profile file, whose keys are the flag names:
+
+  default_profile: local
+  profiles:
+    local:
+      server: localhost:50051
+    staging:
+      server: users.staging.example.com:443
+      tls: true
+      token: ...
+
+Results are printed as a table by default; --output selects json (as returned
+by the REST gateway), yaml, csv or template, which executes the Go template
+given with --template against the JSON fields, e.g. --template '{{.email}}'.
+
+Exit codes:
+  0    success
+  1    other errors
+  2    invalid command line
+  3    InvalidArgument, OutOfRange, FailedPrecondition
+  4    NotFound
+  5    AlreadyExists, Aborted
+  6    Unauthenticated, PermissionDenied
+  7    Unavailable
+  8    DeadlineExceeded
+  9    ResourceExhausted
+  10   Unimplemented
+  130  interrupted`,
+		Version:       version.Version,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			opts.started = true
+			// Cobra checks these after this hook, where they would not be
+			// reported as usage errors
+			if err := cmd.ValidateRequiredFlags(); err != nil {
+				return usageError{err}
+			}
+			if err := cmd.ValidateFlagGroups(); err != nil {
+				return usageError{err}
+			}
+
+			flags := cmd.Root().PersistentFlags()
+			opts.explicit = make(map[string]string)
+			flags.VisitAll(func(f *pflag.Flag) {
+				if f.Changed {
+					opts.explicit[f.Name] = f.Value.String()
+				}
+			})
+			if err := applyProfile(flags, opts); err != nil {
+				return err
+			}
+			opts.outputSet = flags.Changed("output")
+			if _, err := newPrinter(opts, io.Discard); err != nil {
+				return usageError{err}
+			}
+			return nil
+		},
 	}
-} 
\ No newline at end of file
+
+	flags := root.PersistentFlags()
+	flags.StringVarP(&opts.server, "server", "s", "localhost:50051", "Server address in the format host:port")
+	flags.BoolVar(&opts.useTLS, "tls", false, "Use TLS for the connection")
+	flags.StringVar(&opts.tlsCA, "tls-ca", "", "CA certificate file used to verify the server")
+	flags.StringVar(&opts.tlsServerName, "tls-server-name", "", "Override the server name used to verify the certificate")
+	flags.BoolVar(&opts.tlsInsecureSkipVerify, "tls-insecure-skip-verify", false, "Skip server certificate verification")
+	flags.StringVar(&opts.token, "token", "", "Bearer token sent in the authorization header")
+	flags.StringVar(&opts.apiKey, "api-key", "", "API key sent in the x-api-key header")
+	flags.DurationVar(&opts.timeout, "timeout", 10*time.Second, "Deadline for each call; watches are not limited")
+	flags.StringVarP(&opts.profile, "profile", "p", "", "Profile to use from the profile file (default is its default_profile)")
+	flags.StringVar(&opts.configFile, "config", defaultProfileFile(), "Profile file")
+	flags.StringVarP(&opts.output, "output", "o", formatTable, "Output format: "+strings.Join(outputFormats, ", "))
+	flags.StringVar(&opts.columns, "columns", defaultColumns, "Columns of table and CSV output: "+columnNames())
+	flags.StringVar(&opts.template, "template", "", "Go template for --output template")
+
+	root.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+	root.RegisterFlagCompletionFunc("profile", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return profileNames(opts.configFile), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	root.AddCommand(newUsersCommand(opts), newShellCommand(opts), newBenchCommand(opts))
+	return root, opts
+}
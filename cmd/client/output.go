@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+
+	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/user"
+)
+
+// Output formats
+const (
+	formatTable    = "table"
+	formatJSON     = "json"
+	formatYAML     = "yaml"
+	formatCSV      = "csv"
+	formatTemplate = "template"
+)
+
+var outputFormats = []string{formatTable, formatJSON, formatYAML, formatCSV, formatTemplate}
+
+// defaultColumns are the table and CSV columns shown without --columns
+const defaultColumns = "id,name,email,created_at"
+
+// jsonOptions match the REST gateway's JSON representation
+var jsonOptions = protojson.MarshalOptions{EmitUnpopulated: true}
+
+// column is a user field shown in tables and CSV
+type column struct {
+	name   string
+	header string
+	value  func(user *pb.UserResponse, table bool) string
+}
+
+var userColumns = []column{
+	{"id", "ID", func(u *pb.UserResponse, _ bool) string { return u.GetId() }},
+	{"name", "NAME", func(u *pb.UserResponse, _ bool) string { return u.GetName() }},
+	{"email", "EMAIL", func(u *pb.UserResponse, _ bool) string { return u.GetEmail() }},
+	{"created_at", "CREATED", func(u *pb.UserResponse, table bool) string { return formatTime(u.GetCreatedAt(), table) }},
+	{"updated_at", "UPDATED", func(u *pb.UserResponse, table bool) string { return formatTime(u.GetUpdatedAt(), table) }},
+}
+
+// printer writes command results in the selected output format
+type printer struct {
+	w       io.Writer
+	format  string
+	columns []column
+	tmpl    *template.Template
+
+	// csvHeader is set once the CSV header of a stream has been written
+	csvHeader bool
+}
+
+func newPrinter(opts *options, w io.Writer) (*printer, error) {
+	p := &printer{w: w, format: strings.ToLower(opts.output)}
+	if opts.template != "" && p.format == formatTable && !opts.outputSet {
+		p.format = formatTemplate
+	}
+
+	switch p.format {
+	case formatTable, formatCSV:
+		columns, err := parseColumns(opts.columns)
+		if err != nil {
+			return nil, err
+		}
+		p.columns = columns
+	case formatTemplate:
+		if opts.template == "" {
+			return nil, fmt.Errorf("--output template requires --template")
+		}
+		tmpl, err := template.New("output").Option("missingkey=zero").Parse(opts.template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		p.tmpl = tmpl
+	case formatJSON, formatYAML:
+	default:
+		return nil, fmt.Errorf("unknown output format %q, expected one of %s", opts.output, strings.Join(outputFormats, ", "))
+	}
+	return p, nil
+}
+
+// parseColumns resolves a comma-separated column list. Names are matched
+// ignoring case and underscores, so createdAt selects created_at.
+func parseColumns(list string) ([]column, error) {
+	var columns []column
+	for _, name := range strings.Split(list, ",") {
+		key := normalizeColumn(name)
+		if key == "" {
+			continue
+		}
+		found := false
+		for _, c := range userColumns {
+			if normalizeColumn(c.name) == key {
+				columns = append(columns, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q, expected one of %s", strings.TrimSpace(name), columnNames())
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns selected")
+	}
+	return columns, nil
+}
+
+func normalizeColumn(name string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", ""))
+}
+
+func columnNames() string {
+	return strings.Join(columnNamesOf(userColumns), ", ")
+}
+
+func columnNamesOf(columns []column) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// User prints a single user
+func (p *printer) User(user *pb.UserResponse) error {
+	return p.print(user, []*pb.UserResponse{user})
+}
+
+// Users prints a list of users
+func (p *printer) Users(resp *pb.ListUsersResponse) error {
+	return p.print(resp, resp.Users)
+}
+
+// Deleted prints the result of a deletion, which is empty like the REST
+// gateway's response
+func (p *printer) Deleted(id string) error {
+	switch p.format {
+	case formatTable:
+		_, err := fmt.Fprintf(p.w, "Deleted user %s\n", id)
+		return err
+	case formatJSON, formatYAML:
+		return p.print(&emptypb.Empty{}, nil)
+	default:
+		return nil
+	}
+}
+
+// Event prints a user change as it is received. JSON events are written one
+// per line and YAML events as separate documents.
+func (p *printer) Event(event *pb.UserEvent) error {
+	switch p.format {
+	case formatJSON:
+		b, err := jsonOptions.Marshal(event)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.w, "%s\n", b)
+		return err
+	case formatYAML:
+		if _, err := fmt.Fprintln(p.w, "---"); err != nil {
+			return err
+		}
+		return p.print(event, nil)
+	case formatCSV:
+		cw := csv.NewWriter(p.w)
+		if !p.csvHeader {
+			cw.Write(append([]string{"time", "type"}, columnNamesOf(p.columns)...))
+			p.csvHeader = true
+		}
+		cw.Write(append([]string{formatTime(event.Time, false), event.Type.String()}, p.row(event.GetUser(), false)...))
+		cw.Flush()
+		return cw.Error()
+	case formatTable:
+		fields := append([]string{formatTime(event.Time, true), fmt.Sprintf("%-7s", event.Type)}, p.row(event.GetUser(), true)...)
+		_, err := fmt.Fprintln(p.w, strings.Join(fields, "  "))
+		return err
+	default:
+		return p.print(event, nil)
+	}
+}
+
+// print writes msg, or its users for tables and CSV
+func (p *printer) print(msg proto.Message, users []*pb.UserResponse) error {
+	switch p.format {
+	case formatTable:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		headers := make([]string, len(p.columns))
+		for i, c := range p.columns {
+			headers[i] = c.header
+		}
+		fmt.Fprintln(tw, strings.Join(headers, "\t"))
+		for _, user := range users {
+			fmt.Fprintln(tw, strings.Join(p.row(user, true), "\t"))
+		}
+		return tw.Flush()
+	case formatCSV:
+		cw := csv.NewWriter(p.w)
+		cw.Write(columnNamesOf(p.columns))
+		for _, user := range users {
+			cw.Write(p.row(user, false))
+		}
+		cw.Flush()
+		return cw.Error()
+	case formatJSON:
+		b, err := protojson.MarshalOptions{EmitUnpopulated: true, Multiline: true, Indent: "  "}.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.w, "%s\n", b)
+		return err
+	}
+
+	// YAML and templates use the JSON field names and values
+	data, err := toGeneric(msg)
+	if err != nil {
+		return err
+	}
+	if p.format == formatYAML {
+		enc := yaml.NewEncoder(p.w)
+		enc.SetIndent(2)
+		if err := enc.Encode(data); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+
+	var out strings.Builder
+	if err := p.tmpl.Execute(&out, data); err != nil {
+		return fmt.Errorf("template: %w", err)
+	}
+	if !strings.HasSuffix(out.String(), "\n") {
+		out.WriteString("\n")
+	}
+	_, err = io.WriteString(p.w, out.String())
+	return err
+}
+
+func (p *printer) row(user *pb.UserResponse, table bool) []string {
+	row := make([]string, len(p.columns))
+	for i, c := range p.columns {
+		row[i] = c.value(user, table)
+	}
+	return row
+}
+
+// toGeneric converts msg to maps and slices via its JSON representation
+func toGeneric(msg proto.Message) (interface{}, error) {
+	b, err := jsonOptions.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// formatTime renders timestamps in local time for tables and as RFC 3339
+// UTC, like the JSON output, otherwise
+func formatTime(ts *timestamppb.Timestamp, table bool) string {
+	if ts == nil {
+		if table {
+			return "-"
+		}
+		return ""
+	}
+	if table {
+		return ts.AsTime().Local().Format(time.RFC3339)
+	}
+	return ts.AsTime().UTC().Format(time.RFC3339Nano)
+}
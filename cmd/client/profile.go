@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix prefixes the environment variables that set global flags, e.g.
+// USER_CLIENT_SERVER for --server
+const envPrefix = "USER_CLIENT_"
+
+// profileFile lists named connection profiles, for example one per cluster
+type profileFile struct {
+	DefaultProfile string                       `yaml:"default_profile"`
+	Profiles       map[string]map[string]string `yaml:"profiles"`
+}
+
+// defaultProfileFile returns the per-user profile file location
+func defaultProfileFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-grpc-sqlite", "client.yaml")
+}
+
+// envName returns the environment variable for a flag
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// applyProfile fills global flags not set on the command line from the
+// environment, then from the selected profile
+func applyProfile(flags *pflag.FlagSet, opts *options) error {
+	// The profile file and name may themselves come from the environment
+	for _, name := range []string{"config", "profile"} {
+		if err := setFromEnv(flags, name); err != nil {
+			return err
+		}
+	}
+
+	file, err := loadProfileFile(opts.configFile, flags.Changed("config"))
+	if err != nil {
+		return err
+	}
+
+	name := opts.profile
+	if name == "" {
+		name = file.DefaultProfile
+	}
+	var profile map[string]string
+	if name != "" {
+		var ok bool
+		if profile, ok = file.Profiles[name]; !ok {
+			return fmt.Errorf("profile %q not found in %s", name, opts.configFile)
+		}
+	}
+	for key := range profile {
+		if f := flags.Lookup(key); f == nil || key == "config" || key == "profile" {
+			return fmt.Errorf("profile %q: unknown setting %q", name, key)
+		}
+	}
+
+	var applyErr error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if applyErr != nil || f.Changed {
+			return
+		}
+		if err := setFromEnv(flags, f.Name); err != nil {
+			applyErr = err
+			return
+		}
+		if value, ok := profile[f.Name]; ok && !f.Changed {
+			if err := flags.Set(f.Name, value); err != nil {
+				applyErr = fmt.Errorf("profile %q: invalid %s: %w", name, f.Name, err)
+			}
+		}
+	})
+	return applyErr
+}
+
+// setFromEnv sets a flag not given on the command line from its
+// environment variable
+func setFromEnv(flags *pflag.FlagSet, name string) error {
+	if flags.Changed(name) {
+		return nil
+	}
+	value, ok := os.LookupEnv(envName(name))
+	if !ok {
+		return nil
+	}
+	if err := flags.Set(name, value); err != nil {
+		return fmt.Errorf("invalid %s: %w", envName(name), err)
+	}
+	return nil
+}
+
+// loadProfileFile reads the profile file. A missing file is only an error
+// when it was named explicitly.
+func loadProfileFile(path string, explicit bool) (*profileFile, error) {
+	file := &profileFile{}
+	if path == "" {
+		return file, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read profile file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("parse profile file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// profileNames lists the profiles of a profile file, for shell completion
+func profileNames(path string) []string {
+	file, err := loadProfileFile(path, false)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(file.Profiles))
+	for name := range file.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,601 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/user"
+)
+
+// maxShellIDs bounds the user IDs remembered for completion
+const maxShellIDs = 1000
+
+const shellHelp = `Commands:
+  create, get, list, update, delete, search, watch, import, export
+                       user commands, also available by RPC name (GetUser, ...)
+  connect ADDRESS      switch to another server
+  set FLAG VALUE       set a global flag for the session, e.g. set token abc
+  unset FLAG           revert a global flag to its environment, profile or default
+  show                 print the session settings
+  timing on|off        print the latency of every call (default on)
+  help [COMMAND]       print this help, or the help of a command
+  exit, quit, Ctrl-D   leave the shell
+
+Ctrl-C cancels the running command. Tab completes commands, flags and the
+user IDs seen in the session.`
+
+// shellBuiltins are the commands handled by the shell itself
+var shellBuiltins = []string{"connect", "set", "unset", "show", "timing", "help", "exit", "quit"}
+
+// secretFlags are masked by show and kept out of the history
+var secretFlags = map[string]bool{"token": true, "api-key": true}
+
+func newShellCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:     "shell",
+		Aliases: []string{"repl"},
+		Short:   "Run commands interactively over one connection",
+		Long: `Run commands interactively over one connection.
+
+The global flags of the shell command apply to every command of the session
+and can be changed with set, unset and connect without restarting. Commands
+are entered without the "users" prefix, e.g. "get ID". History is kept in the
+user cache directory, leaving out lines that set a token or API key.
+
+` + shellHelp,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := newShell(opts.explicit, cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+			defer s.conns.Close()
+			// Interrupts cancel single commands rather than the shell
+			return s.run(context.WithoutCancel(cmd.Context()))
+		},
+	}
+}
+
+// shell is an interactive session
+type shell struct {
+	in          io.Reader
+	out, errOut io.Writer
+
+	// flags are the global flags set for the session
+	flags map[string]string
+	// conns keeps the connection open between commands
+	conns  *connCache
+	timing bool
+
+	mu  sync.Mutex
+	ids map[string]time.Time // user IDs seen, with when they were last seen
+}
+
+func newShell(flags map[string]string, in io.Reader, out, errOut io.Writer) *shell {
+	s := &shell{
+		in:     in,
+		out:    out,
+		errOut: errOut,
+		flags:  make(map[string]string),
+		timing: true,
+		ids:    make(map[string]time.Time),
+	}
+	for name, value := range flags {
+		s.flags[name] = value
+	}
+	s.conns = &connCache{dialOptions: []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.WithChainStreamInterceptor(s.streamInterceptor),
+	}}
+	return s
+}
+
+// historyFile returns the location of the shell history
+func historyFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-grpc-sqlite", "shell_history")
+}
+
+func (s *shell) run(ctx context.Context) error {
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	line.SetTabCompletionStyle(liner.TabPrints)
+	line.SetWordCompleter(s.complete)
+
+	history := historyFile()
+	if history != "" {
+		if f, err := os.Open(history); err == nil {
+			line.ReadHistory(f)
+			f.Close()
+		}
+		defer func() {
+			if err := os.MkdirAll(filepath.Dir(history), 0o700); err != nil {
+				return
+			}
+			if f, err := os.OpenFile(history, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600); err == nil {
+				// A history written by an older version may be readable by others
+				f.Chmod(0o600)
+				line.WriteHistory(f)
+				f.Close()
+			}
+		}()
+	}
+
+	fmt.Fprintf(s.errOut, "Using %s. Type help for commands.\n", s.settings().server)
+	for {
+		input, err := line.Prompt(s.prompt())
+		if errors.Is(err, liner.ErrPromptAborted) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(s.errOut)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		words, err := splitWords(input)
+		if err != nil {
+			fmt.Fprintf(s.errOut, "Error: %s\n", err)
+			continue
+		}
+		if len(words) == 0 {
+			continue
+		}
+		if keepInHistory(words) {
+			line.AppendHistory(strings.TrimSpace(input))
+		}
+
+		if words[0] == "exit" || words[0] == "quit" {
+			return nil
+		}
+		s.exec(ctx, words)
+	}
+}
+
+// keepInHistory reports whether a command line may be written to the
+// history file. Lines setting a credential are left out, since the file is
+// plain text.
+func keepInHistory(words []string) bool {
+	if words[0] == "set" && len(words) > 1 && secretFlags[strings.TrimLeft(words[1], "-")] {
+		return false
+	}
+	for _, w := range words {
+		name, _, _ := strings.Cut(strings.TrimLeft(w, "-"), "=")
+		if strings.HasPrefix(w, "-") && secretFlags[name] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *shell) prompt() string {
+	return s.settings().server + "> "
+}
+
+// exec runs a builtin or a client command. Errors have been printed.
+func (s *shell) exec(ctx context.Context, words []string) {
+	var err error
+	switch name, args := words[0], words[1:]; name {
+	case "connect":
+		if len(args) != 1 {
+			err = errors.New("usage: connect ADDRESS")
+			break
+		}
+		err = s.set("server", args[0])
+	case "set":
+		if len(args) != 2 {
+			err = errors.New("usage: set FLAG VALUE")
+			break
+		}
+		err = s.set(strings.TrimLeft(args[0], "-"), args[1])
+	case "unset":
+		if len(args) != 1 {
+			err = errors.New("usage: unset FLAG")
+			break
+		}
+		err = s.unset(strings.TrimLeft(args[0], "-"))
+	case "show":
+		s.show()
+	case "timing":
+		if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+			err = errors.New("usage: timing on|off")
+			break
+		}
+		s.timing = args[0] == "on"
+	case "help":
+		if len(args) == 0 {
+			fmt.Fprintln(s.out, shellHelp)
+			break
+		}
+		s.command(ctx, append(args, "--help"))
+	case "shell", "repl":
+		err = errors.New("already in the shell")
+	default:
+		s.command(ctx, words)
+	}
+	if err != nil {
+		fmt.Fprintf(s.errOut, "Error: %s\n", err)
+	}
+}
+
+// command runs a client command line with the session flags, until it
+// completes or is interrupted
+func (s *shell) command(ctx context.Context, words []string) {
+	if isUserCommand(words[0]) {
+		words = append([]string{"users"}, words...)
+	}
+
+	root, opts := newRootCommand()
+	root.SetArgs(append(s.args(), words...))
+	root.SetIn(s.in)
+	root.SetOut(s.out)
+	root.SetErr(s.errOut)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	execute(withConnCache(ctx, s.conns), root, opts)
+}
+
+// args returns the session flags as command line arguments
+func (s *shell) args() []string {
+	names := make([]string, 0, len(s.flags))
+	for name := range s.flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := make([]string, len(names))
+	for i, name := range names {
+		args[i] = fmt.Sprintf("--%s=%s", name, s.flags[name])
+	}
+	return args
+}
+
+// set validates and sets a global flag for the session
+func (s *shell) set(name, value string) error {
+	root, _ := newRootCommand()
+	f := root.PersistentFlags().Lookup(name)
+	if f == nil {
+		return fmt.Errorf("unknown flag %q", name)
+	}
+	if err := f.Value.Set(value); err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	s.flags[name] = value
+	return nil
+}
+
+func (s *shell) unset(name string) error {
+	root, _ := newRootCommand()
+	if root.PersistentFlags().Lookup(name) == nil {
+		return fmt.Errorf("unknown flag %q", name)
+	}
+	delete(s.flags, name)
+	return nil
+}
+
+// settings resolves the session flags with the environment and profile,
+// falling back to the flags alone if the profile cannot be applied
+func (s *shell) settings() *options {
+	root, opts := newRootCommand()
+	flags := root.PersistentFlags()
+	flags.Parse(s.args())
+	if err := applyProfile(flags, opts); err != nil {
+		root, opts = newRootCommand()
+		root.PersistentFlags().Parse(s.args())
+	}
+	return opts
+}
+
+// show prints the effective global flags, masking credentials
+func (s *shell) show() {
+	root, opts := newRootCommand()
+	flags := root.PersistentFlags()
+	flags.Parse(s.args())
+	if err := applyProfile(flags, opts); err != nil {
+		fmt.Fprintf(s.errOut, "Error: %s\n", err)
+	}
+
+	flags.VisitAll(func(f *pflag.Flag) {
+		value := f.Value.String()
+		if secretFlags[f.Name] && value != "" {
+			value = "****"
+		}
+		source := ""
+		if _, ok := s.flags[f.Name]; ok {
+			source = " (set)"
+		}
+		fmt.Fprintf(s.out, "%-25s %s%s\n", f.Name, value, source)
+	})
+}
+
+// unaryInterceptor prints the latency of calls and remembers the users
+// they return
+func (s *shell) unaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if s.timing {
+		fmt.Fprintf(s.errOut, "%s: %s in %s\n", path.Base(method), status.Code(err), time.Since(start).Round(time.Microsecond))
+	}
+	if err != nil {
+		return err
+	}
+
+	switch r := reply.(type) {
+	case *pb.UserResponse:
+		s.remember(r)
+	case *pb.ListUsersResponse:
+		s.remember(r.Users...)
+	case *pb.SearchUsersResponse:
+		s.remember(r.Users...)
+	}
+	if r, ok := req.(*pb.DeleteUserRequest); ok {
+		s.forget(r.Id)
+	}
+	return nil
+}
+
+// streamInterceptor prints how long streams take to open and remembers the
+// users of watch events
+func (s *shell) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	start := time.Now()
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if s.timing {
+		fmt.Fprintf(s.errOut, "%s: stream %s in %s\n", path.Base(method), status.Code(err), time.Since(start).Round(time.Microsecond))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &watchedStream{ClientStream: stream, shell: s}, nil
+}
+
+type watchedStream struct {
+	grpc.ClientStream
+	shell *shell
+}
+
+func (w *watchedStream) RecvMsg(m interface{}) error {
+	err := w.ClientStream.RecvMsg(m)
+	if event, ok := m.(*pb.UserEvent); ok && err == nil {
+		if event.Type == pb.UserEvent_DELETED {
+			w.shell.forget(event.GetUser().GetId())
+		} else if event.User != nil {
+			w.shell.remember(event.User)
+		}
+	}
+	return err
+}
+
+func (s *shell) remember(users ...*pb.UserResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, u := range users {
+		s.ids[u.Id] = now
+	}
+
+	// Forget the least recently seen IDs beyond the limit
+	if len(s.ids) > maxShellIDs {
+		ids := s.sortedIDs()
+		for _, id := range ids[maxShellIDs:] {
+			delete(s.ids, id)
+		}
+	}
+}
+
+func (s *shell) forget(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.ids, id)
+}
+
+// sortedIDs returns the remembered IDs, most recently seen first. The
+// caller holds s.mu.
+func (s *shell) sortedIDs() []string {
+	ids := make([]string, 0, len(s.ids))
+	for id := range s.ids {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if ti, tj := s.ids[ids[i]], s.ids[ids[j]]; !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// complete completes the word before the cursor
+func (s *shell) complete(line string, pos int) (head string, completions []string, tail string) {
+	head, tail = line[:pos], line[pos:]
+	start := strings.LastIndexAny(head, " \t") + 1
+	word := head[start:]
+	for _, candidate := range s.candidates(strings.Fields(head[:start]), word) {
+		if strings.HasPrefix(candidate, word) {
+			completions = append(completions, candidate)
+		}
+	}
+	return head[:start], completions, tail
+}
+
+// candidates lists the completions of a word following words
+func (s *shell) candidates(words []string, word string) []string {
+	if len(words) > 0 && words[0] == "users" {
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return append(append([]string{}, shellBuiltins...), userCommandNames()...)
+	}
+
+	root, _ := newRootCommand()
+	last := words[len(words)-1]
+	switch {
+	case (words[0] == "set" || words[0] == "unset") && len(words) == 1:
+		var names []string
+		root.PersistentFlags().VisitAll(func(f *pflag.Flag) { names = append(names, f.Name) })
+		return names
+	case words[0] == "set" && len(words) == 2:
+		return flagValues(words[1], s.settings().configFile)
+	case words[0] == "timing" && len(words) == 1:
+		return []string{"on", "off"}
+	case words[0] == "help" && len(words) == 1:
+		return userCommandNames()
+	case last == "-o" || last == "--output":
+		return outputFormats
+	case last == "-p" || last == "--profile":
+		return profileNames(s.settings().configFile)
+	}
+
+	cmd, _, err := root.Find([]string{"users", words[0]})
+	if err != nil {
+		return nil
+	}
+	if strings.HasPrefix(word, "-") {
+		var names []string
+		add := func(f *pflag.Flag) { names = append(names, "--"+f.Name) }
+		cmd.LocalFlags().VisitAll(add)
+		cmd.InheritedFlags().VisitAll(add)
+		return names
+	}
+
+	if takesUserID(words[0]) && len(positional(cmd, words[1:])) == 0 {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.sortedIDs()
+	}
+	return nil
+}
+
+// flagValues lists the values of global flags with a fixed set of them
+func flagValues(name, configFile string) []string {
+	switch name {
+	case "output":
+		return outputFormats
+	case "profile":
+		return profileNames(configFile)
+	case "tls", "tls-insecure-skip-verify":
+		return []string{"true", "false"}
+	}
+	return nil
+}
+
+// positional returns the arguments of cmd among words, skipping flags and
+// their values
+func positional(cmd *cobra.Command, words []string) []string {
+	var args []string
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if !strings.HasPrefix(w, "-") {
+			args = append(args, w)
+			continue
+		}
+		if strings.Contains(w, "=") {
+			continue
+		}
+		for _, flags := range []*pflag.FlagSet{cmd.LocalFlags(), cmd.InheritedFlags()} {
+			var f *pflag.Flag
+			if name := strings.TrimPrefix(w, "--"); name != w {
+				f = flags.Lookup(name)
+			} else {
+				f = flags.ShorthandLookup(strings.TrimPrefix(w, "-"))
+			}
+			if f != nil {
+				if f.NoOptDefVal == "" {
+					i++ // the flag's value
+				}
+				break
+			}
+		}
+	}
+	return args
+}
+
+// userCommandNames lists the user commands and their RPC names
+func userCommandNames() []string {
+	var names []string
+	for _, cmd := range newUsersCommand(&options{}).Commands() {
+		names = append(names, cmd.Name())
+		names = append(names, cmd.Aliases...)
+	}
+	return names
+}
+
+func isUserCommand(name string) bool {
+	for _, n := range userCommandNames() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// takesUserID reports whether a user command's argument is a user ID
+func takesUserID(name string) bool {
+	switch name {
+	case "get", "GetUser", "update", "UpdateUser", "delete", "DeleteUser":
+		return true
+	}
+	return false
+}
+
+// splitWords splits a command line into words, honouring single and double
+// quotes and backslash escapes
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
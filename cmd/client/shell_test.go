@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		words   []string
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"blank", " \t ", nil, false},
+		{"plain words", "users get 42", []string{"users", "get", "42"}, false},
+		{"repeated separators", "  users \t list  ", []string{"users", "list"}, false},
+		{"double quotes", `users create --name "Ada Lovelace"`, []string{"users", "create", "--name", "Ada Lovelace"}, false},
+		{"single quotes", `search 'a b'`, []string{"search", "a b"}, false},
+		{"quotes join words", `--name="Ada Lovelace"x`, []string{"--name=Ada Lovelacex"}, false},
+		{"empty quotes", `set name ""`, []string{"set", "name", ""}, false},
+		{"other quote inside", `say "it's" 'a "b"'`, []string{"say", "it's", `a "b"`}, false},
+		{"escaped space", `a\ b c`, []string{"a b", "c"}, false},
+		{"escape in double quotes", `"a\"b"`, []string{`a"b`}, false},
+		{"no escape in single quotes", `'a\b'`, []string{`a\b`}, false},
+		{"escaped backslash", `a\\b`, []string{`a\b`}, false},
+		{"unterminated double quote", `users create "Ada`, nil, true},
+		{"unterminated single quote", `'Ada`, nil, true},
+		{"trailing escape", `users\`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words, err := splitWords(tt.line)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.words, words)
+		})
+	}
+}
+
+func TestKeepInHistory(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"get 42", true},
+		{"set server localhost:50051", true},
+		{"set token x", false},
+		{"set --token x", false},
+		{"set api-key 0123456789abcdef", false},
+		{"unset token", true},
+		{"show", true},
+		{"get 42 --token x", false},
+		{"get 42 --api-key=0123456789abcdef", false},
+		{"search --query token", true},
+		{"create --name token", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			words, err := splitWords(tt.line)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, keepInHistory(words))
+		})
+	}
+}
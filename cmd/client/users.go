@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/user"
+)
+
+func newUsersCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "users",
+		Aliases: []string{"user"},
+		Short:   "Manage users",
+		// Runnable so that unknown subcommands are rejected instead of
+		// printing the help
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		newCreateCommand(opts),
+		newGetCommand(opts),
+		newListCommand(opts),
+		newUpdateCommand(opts),
+		newDeleteCommand(opts),
+		newSearchCommand(opts),
+		newWatchCommand(opts),
+		newImportCommand(opts),
+		newExportCommand(opts),
+	)
+	return cmd
+}
+
+// withClient runs fn with a connected client and a printer for the output
+// format, closing the connection afterwards
+func withClient(cmd *cobra.Command, opts *options, fn func(client pb.UserServiceClient, out *printer) error) error {
+	out, err := newPrinter(opts, cmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+	client, closeConn, err := dial(cmd.Context(), opts)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+	return fn(client, out)
+}
+
+func newCreateCommand(opts *options) *cobra.Command {
+	var name, email string
+	cmd := &cobra.Command{
+		Use:     "create --name NAME --email EMAIL",
+		Aliases: []string{"CreateUser"},
+		Short:   "Create a user",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withClient(cmd, opts, func(client pb.UserServiceClient, out *printer) error {
+				ctx, cancel := callContext(cmd.Context(), opts)
+				defer cancel()
+
+				user, err := client.CreateUser(ctx, &pb.CreateUserRequest{Name: name, Email: email})
+				if err != nil {
+					return err
+				}
+				return out.User(user)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "", "The user's name")
+	cmd.Flags().StringVar(&email, "email", "", "The user's email address")
+	cmd.MarkFlagRequired("name")
+	cmd.MarkFlagRequired("email")
+	return cmd
+}
+
+func newGetCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:     "get ID",
+		Aliases: []string{"GetUser"},
+		Short:   "Get a user by ID",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withClient(cmd, opts, func(client pb.UserServiceClient, out *printer) error {
+				ctx, cancel := callContext(cmd.Context(), opts)
+				defer cancel()
+
+				user, err := client.GetUser(ctx, &pb.GetUserRequest{Id: args[0]})
+				if err != nil {
+					return err
+				}
+				return out.User(user)
+			})
+		},
+	}
+}
+
+// pageFlags are the paging flags of list and search
+type pageFlags struct {
+	size  int32
+	token string
+	all   bool
+}
+
+func (p *pageFlags) register(cmd *cobra.Command) {
+	cmd.Flags().Int32Var(&p.size, "page-size", 0, "Number of users per page (server default 50, at most 1000)")
+	cmd.Flags().StringVar(&p.token, "page-token", "", "Page token from a previous call")
+	cmd.Flags().BoolVar(&p.all, "all", false, "Fetch every page")
+	cmd.MarkFlagsMutuallyExclusive("page-token", "all")
+}
+
+// fetchPages calls fetch for one page, or for every page with --all, and
+// prints the users as one list
+func fetchPages(cmd *cobra.Command, opts *options, out *printer, page pageFlags, fetch func(ctx context.Context, token string) ([]*pb.UserResponse, string, error)) error {
+	var users []*pb.UserResponse
+	token := page.token
+	for {
+		ctx, cancel := callContext(cmd.Context(), opts)
+		batch, next, err := fetch(ctx, token)
+		cancel()
+		if err != nil {
+			return err
+		}
+		users = append(users, batch...)
+		token = next
+		if !page.all || token == "" {
+			break
+		}
+	}
+
+	if err := out.Users(&pb.ListUsersResponse{Users: users, NextPageToken: token}); err != nil {
+		return err
+	}
+	if token != "" && out.format == formatTable {
+		fmt.Fprintf(cmd.ErrOrStderr(), "More users available: --page-token %s\n", token)
+	}
+	return nil
+}
+
+func newListCommand(opts *options) *cobra.Command {
+	var page pageFlags
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ListUsers"},
+		Short:   "List users",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withClient(cmd, opts, func(client pb.UserServiceClient, out *printer) error {
+				return fetchPages(cmd, opts, out, page, func(ctx context.Context, token string) ([]*pb.UserResponse, string, error) {
+					resp, err := client.ListUsers(ctx, &pb.ListUsersRequest{PageSize: page.size, PageToken: token})
+					if err != nil {
+						return nil, "", err
+					}
+					return resp.Users, resp.NextPageToken, nil
+				})
+			})
+		},
+	}
+	page.register(cmd)
+	return cmd
+}
+
+func newSearchCommand(opts *options) *cobra.Command {
+	var page pageFlags
+	cmd := &cobra.Command{
+		Use:     "search QUERY",
+		Aliases: []string{"SearchUsers"},
+		Short:   "Search users by name or email",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withClient(cmd, opts, func(client pb.UserServiceClient, out *printer) error {
+				return fetchPages(cmd, opts, out, page, func(ctx context.Context, token string) ([]*pb.UserResponse, string, error) {
+					resp, err := client.SearchUsers(ctx, &pb.SearchUsersRequest{Query: args[0], PageSize: page.size, PageToken: token})
+					if err != nil {
+						return nil, "", err
+					}
+					return resp.Users, resp.NextPageToken, nil
+				})
+			})
+		},
+	}
+	page.register(cmd)
+	return cmd
+}
+
+func newUpdateCommand(opts *options) *cobra.Command {
+	var name, email string
+	cmd := &cobra.Command{
+		Use:     "update ID [--name NAME] [--email EMAIL]",
+		Aliases: []string{"UpdateUser"},
+		Short:   "Update a user's name and/or email",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withClient(cmd, opts, func(client pb.UserServiceClient, out *printer) error {
+				ctx, cancel := callContext(cmd.Context(), opts)
+				defer cancel()
+
+				user, err := client.UpdateUser(ctx, &pb.UpdateUserRequest{Id: args[0], Name: name, Email: email})
+				if err != nil {
+					return err
+				}
+				return out.User(user)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "", "The new name")
+	cmd.Flags().StringVar(&email, "email", "", "The new email address")
+	cmd.MarkFlagsOneRequired("name", "email")
+	return cmd
+}
+
+func newDeleteCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete ID",
+		Aliases: []string{"DeleteUser"},
+		Short:   "Delete a user",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withClient(cmd, opts, func(client pb.UserServiceClient, out *printer) error {
+				ctx, cancel := callContext(cmd.Context(), opts)
+				defer cancel()
+
+				if _, err := client.DeleteUser(ctx, &pb.DeleteUserRequest{Id: args[0]}); err != nil {
+					return err
+				}
+				return out.Deleted(args[0])
+			})
+		},
+	}
+}
+
+func newWatchCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:     "watch",
+		Aliases: []string{"WatchUsers"},
+		Short:   "Stream user changes until interrupted",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withClient(cmd, opts, func(client pb.UserServiceClient, out *printer) error {
+				stream, err := client.WatchUsers(cmd.Context(), &pb.WatchUsersRequest{})
+				if err != nil {
+					return err
+				}
+				for {
+					event, err := stream.Recv()
+					switch {
+					case errors.Is(err, io.EOF):
+						return nil
+					case status.Code(err) == codes.Canceled && cmd.Context().Err() != nil:
+						// Interrupted by the user
+						return nil
+					case err != nil:
+						return err
+					}
+					if err := out.Event(event); err != nil {
+						return err
+					}
+				}
+			})
+		},
+	}
+}
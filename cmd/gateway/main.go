@@ -2,37 +2,59 @@ package main
 
 import (
 	"context"
-	"embed"
 	"flag"
 	"fmt"
-	"io/fs"
-	"mime"
 	"net/http"
 	"os"
-	"path"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
 	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/user"
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/gateway"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/health"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/listener"
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/shutdown"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/swagger"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/upstream"
 )
 
-//go:embed swagger-ui
-var swaggerUI embed.FS
-
 var (
-	grpcServerEndpoint = flag.String("grpc-server-endpoint", "localhost:50051", "gRPC server endpoint")
+	grpcServerEndpoint = flag.String("grpc-server-endpoint", "localhost:50051", "gRPC server endpoint, e.g. dns:///user-service:50051 or unix:///run/app/grpc.sock")
 	httpPort           = flag.Int("http-port", 8080, "HTTP server port")
+	listenAddrs        listFlag
 )
 
+func init() {
+	flag.Var(&listenAddrs, "listen", "Address to listen on instead of gateway.listen and -http-port: tcp://host:port or unix:///path/to.sock?mode=0660 (repeatable)")
+}
+
+// listFlag collects the values of a repeated flag
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
 func main() {
 	flag.Parse()
 
+	// "gateway config validate|print" inspects the configuration and exits
+	if flag.Arg(0) == "config" {
+		os.Exit(config.RunCommand("gateway", flag.Args()[1:], os.Stdout, os.Stderr))
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -41,7 +63,10 @@ func main() {
 	}
 
 	// Initialize logger
-	logger.Init(cfg.App.Environment)
+	if err := logger.Setup(cfg); err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
 	defer logger.Sync()
 
 	logger.Info("Starting gateway server",
@@ -53,19 +78,19 @@ func main() {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	// Create gRPC connection to the server
-	conn, err := grpc.DialContext(
-		ctx,
-		*grpcServerEndpoint,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	// Create gRPC connection to the server, balanced across its instances
+	// with retries, health checking and a circuit breaker
+	conn, err := upstream.Dial(*grpcServerEndpoint, cfg.Gateway.Upstream, pb.UserService_ServiceDesc)
 	if err != nil {
 		logger.Fatal("Failed to dial gRPC server", zap.Error(err))
 	}
-	defer conn.Close()
 
 	// Create a new ServeMux for the HTTP server
-	gwmux := runtime.NewServeMux()
+	muxOptions, err := gateway.ServeMuxOptions(cfg.Gateway)
+	if err != nil {
+		logger.Fatal("Invalid gateway configuration", zap.Error(err))
+	}
+	gwmux := runtime.NewServeMux(muxOptions...)
 
 	// Register gRPC service handlers
 	err = pb.RegisterUserServiceHandler(ctx, gwmux, conn)
@@ -79,62 +104,80 @@ func main() {
 	// Register gRPC-Gateway handlers
 	mux.Handle("/v1/", gwmux)
 
-	// Register Swagger UI handlers
-	mux.HandleFunc("/swagger/", serveSwaggerUI)
-	mux.HandleFunc("/swagger.json", serveSwaggerJSON)
+	// Register Kubernetes probe handlers. The gateway is ready only while
+	// the upstream gRPC server reports SERVING.
+	checks := health.NewRegistry(
+		time.Duration(cfg.Health.CheckInterval)*time.Second,
+		time.Duration(cfg.Health.CheckTimeout)*time.Second,
+	)
+	checks.Register(health.Readiness, health.GRPCHealth("upstream", conn, ""))
+	checks.Start(ctx)
+	mux.Handle("/livez", checks.Handler(health.Liveness))
+	mux.Handle("/readyz", checks.Handler(health.Readiness))
 
-	// Start HTTP server
-	addr := fmt.Sprintf(":%d", *httpPort)
-	logger.Info("HTTP server listening", zap.String("address", addr))
-	if err := http.ListenAndServe(addr, mux); err != nil {
-		logger.Fatal("Failed to start HTTP server", zap.Error(err))
+	// Register Swagger UI and OpenAPI document handlers
+	spec, err := swagger.NewSpec(cfg.Gateway.BasePath, cfg.Gateway.TrustedProxies)
+	if err != nil {
+		logger.Fatal("Failed to load OpenAPI spec", zap.Error(err))
 	}
-}
+	spec.Register(mux)
 
-// serveSwaggerUI serves the Swagger UI files
-func serveSwaggerUI(w http.ResponseWriter, r *http.Request) {
-	// Strip the /swagger/ prefix
-	uiPath := strings.TrimPrefix(r.URL.Path, "/swagger/")
-	if uiPath == "" {
-		uiPath = "index.html"
+	// Expose Prometheus metrics, including the gateway's HTTP metrics
+	if cfg.Gateway.MetricsPath != "" {
+		mux.Handle(cfg.Gateway.MetricsPath, promhttp.Handler())
 	}
 
-	// Set content type based on file extension
-	ext := path.Ext(uiPath)
-	if ext != "" {
-		contentType := mime.TypeByExtension(ext)
-		if contentType != "" {
-			w.Header().Set("Content-Type", contentType)
-		}
+	// The log level and CORS settings follow changes to the config file;
+	// other changes need a restart
+	corsPolicy := gateway.NewCORSPolicy(cfg.Gateway.CORS)
+	if watcher := logger.WatchConfig(cfg); watcher != nil {
+		watcher.Subscribe(func(old, updated *config.Config) {
+			corsPolicy.Update(updated.Gateway.CORS)
+		})
 	}
 
-	// Open and serve the file from the embedded filesystem
-	content, err := fs.ReadFile(swaggerUI, "swagger-ui/"+uiPath)
+	handler, err := gateway.Middleware(cfg.Gateway, corsPolicy, mux)
 	if err != nil {
-		http.Error(w, "Not found", http.StatusNotFound)
-		return
+		logger.Fatal("Invalid gateway configuration", zap.Error(err))
 	}
 
-	_, err = w.Write(content)
-	if err != nil {
-		logger.Error("Failed to write response", zap.Error(err))
+	// Start HTTP server on every listen address given by -listen or
+	// gateway.listen, or on -http-port
+	addrs := []string(listenAddrs)
+	if len(addrs) == 0 {
+		addrs = cfg.Gateway.Listen
 	}
-}
-
-// serveSwaggerJSON serves the OpenAPI/Swagger JSON specification
-func serveSwaggerJSON(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	
-	// Read and serve the swagger JSON file
-	json, err := os.ReadFile("api/swagger/api/user.swagger.json")
-	if err != nil {
-		http.Error(w, "Failed to read Swagger JSON", http.StatusInternalServerError)
-		logger.Error("Failed to read Swagger JSON", zap.Error(err))
-		return
+	if len(addrs) == 0 {
+		addrs = []string{fmt.Sprintf(":%d", *httpPort)}
 	}
-	
-	_, err = w.Write(json)
-	if err != nil {
-		logger.Error("Failed to write response", zap.Error(err))
+	server := &http.Server{Handler: handler}
+	for _, addr := range addrs {
+		lis, err := listener.Listen(addr)
+		if err != nil {
+			logger.Fatal("Failed to start HTTP server", zap.String("address", addr), zap.Error(err))
+		}
+		go func() {
+			logger.Info("HTTP server listening", zap.String("address", listener.URL(lis)))
+			if err := server.Serve(lis); err != nil && err != http.ErrServerClosed {
+				logger.Fatal("Failed to start HTTP server", zap.Error(err))
+			}
+		}()
 	}
-} 
\ No newline at end of file
+
+	// Report not ready first so load balancers stop routing, then drain
+	// in-flight requests before closing the upstream connection
+	coordinator := shutdown.New(
+		time.Duration(cfg.Shutdown.PreStopDelay)*time.Second,
+		time.Duration(cfg.Shutdown.DrainTimeout)*time.Second,
+	)
+	coordinator.OnNotServing(checks.Shutdown)
+	coordinator.OnDrain("http", shutdown.HTTPServer(server))
+	coordinator.OnClose("upstream", conn.Close)
+	coordinator.OnClose("logger", func() error {
+		logger.Info("Gateway stopped")
+		logger.Sync()
+		return nil
+	})
+
+	coordinator.Wait(os.Interrupt, syscall.SIGTERM)
+}
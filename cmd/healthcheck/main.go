@@ -2,52 +2,170 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"time"
-
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
+// serviceList collects repeated --service flags, also accepting comma-separated values
+type serviceList []string
+
+func (s *serviceList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *serviceList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		*s = append(*s, strings.TrimSpace(v))
+	}
+	return nil
+}
+
+// result is the outcome of probing a single target
+type result struct {
+	Target   string  `json:"target"`
+	Service  string  `json:"service,omitempty"`
+	Status   string  `json:"status"`
+	Healthy  bool    `json:"healthy"`
+	Error    string  `json:"error,omitempty"`
+	Attempts int     `json:"attempts"`
+	Duration float64 `json:"duration_ms"`
+}
+
 func main() {
 	// Parse flags
-	service := flag.String("service", "", "The service name to check (default is empty, which checks the server's overall health)")
+	var services serviceList
+	flag.Var(&services, "service", "Service name to check; repeat or comma-separate for several (default is empty, which checks the server's overall health)")
 	addr := flag.String("addr", "localhost:50051", "The server address to check")
-	timeout := flag.Duration("timeout", time.Second*3, "The timeout for the health check")
+	timeout := flag.Duration("timeout", time.Second*3, "The timeout for a single health check attempt")
+	watch := flag.Bool("watch", false, "Stream Watch updates and exit when a service's status changes")
+	httpURL := flag.String("http", "", "Probe an HTTP endpoint instead of gRPC, e.g. http://localhost:8080/readyz")
+	wait := flag.Duration("wait", 0, "Keep retrying until every target is healthy or this deadline passes (0 checks once)")
+	interval := flag.Duration("interval", time.Second, "Delay between retries when --wait is set")
+	output := flag.String("output", "text", "Output format: text or json")
+	useTLS := flag.Bool("tls", false, "Use TLS for the connection")
+	caFile := flag.String("tls-ca", "", "CA certificate file used to verify the server")
+	serverName := flag.String("tls-server-name", "", "Override the server name used to verify the certificate")
+	insecureSkipVerify := flag.Bool("tls-insecure-skip-verify", false, "Skip server certificate verification")
+	token := flag.String("token", "", "Bearer token sent in the authorization header")
 	flag.Parse()
 
-	// Set up a connection to the server
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
-	defer cancel()
+	if len(services) == 0 {
+		services = serviceList{""}
+	}
 
-	conn, err := grpc.DialContext(ctx, *addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	tlsConfig, err := newTLSConfig(*useTLS, *caFile, *serverName, *insecureSkipVerify)
 	if err != nil {
-		fmt.Printf("Failed to connect: %v\n", err)
+		fmt.Printf("Invalid TLS configuration: %v\n", err)
 		os.Exit(1)
 	}
-	defer conn.Close()
 
-	// Create a health client
-	healthClient := grpc_health_v1.NewHealthClient(conn)
+	// The overall deadline bounds retries and watches
+	ctx := context.Background()
+	if *wait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *wait)
+		defer cancel()
+	}
 
-	// Perform health check
-	resp, err := healthClient.Check(ctx, &grpc_health_v1.HealthCheckRequest{
-		Service: *service,
-	})
-	if err != nil {
-		fmt.Printf("Health check failed: %v\n", err)
-		os.Exit(1)
+	var probe func(ctx context.Context, service string) result
+	switch {
+	case *httpURL != "":
+		probe = func(ctx context.Context, _ string) result {
+			return checkHTTP(ctx, *httpURL, *token, tlsConfig, *timeout)
+		}
+		services = serviceList{""}
+	default:
+		conn, err := dial(*addr, tlsConfig, *token)
+		if err != nil {
+			fmt.Printf("Failed to connect: %v\n", err)
+			os.Exit(1)
+		}
+		defer conn.Close()
+
+		if *watch {
+			probe = func(ctx context.Context, service string) result {
+				return watchGRPC(ctx, conn, *addr, service)
+			}
+		} else {
+			probe = func(ctx context.Context, service string) result {
+				return checkGRPC(ctx, conn, *addr, service, *timeout)
+			}
+		}
 	}
 
-	// Check status
-	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
-		fmt.Printf("Service is not serving: %s\n", resp.Status.String())
-		os.Exit(1)
+	// Check every service concurrently
+	results := make([]result, len(services))
+	var wg sync.WaitGroup
+	for i, service := range services {
+		wg.Add(1)
+		go func(i int, service string) {
+			defer wg.Done()
+			if *watch || *wait <= 0 {
+				results[i] = probe(ctx, service)
+				results[i].Attempts = 1
+				return
+			}
+			results[i] = retry(ctx, *interval, func() result { return probe(ctx, service) })
+		}(i, service)
 	}
+	wg.Wait()
 
-	fmt.Println("Service is healthy")
+	healthy := true
+	for _, r := range results {
+		healthy = healthy && r.Healthy
+	}
+	report(*output, healthy, results)
+
+	if !healthy {
+		os.Exit(1)
+	}
 	os.Exit(0)
-} 
\ No newline at end of file
+}
+
+// retry probes until the result is healthy or ctx expires, returning the last result
+func retry(ctx context.Context, interval time.Duration, probe func() result) result {
+	var r result
+	for attempt := 1; ; attempt++ {
+		r = probe()
+		r.Attempts = attempt
+		if r.Healthy {
+			return r
+		}
+
+		select {
+		case <-ctx.Done():
+			return r
+		case <-time.After(interval):
+		}
+	}
+}
+
+// report prints the results in the requested format
+func report(output string, healthy bool, results []result) {
+	if output == "json" {
+		body, _ := json.MarshalIndent(struct {
+			Healthy bool     `json:"healthy"`
+			Results []result `json:"results"`
+		}{healthy, results}, "", "  ")
+		fmt.Println(string(body))
+		return
+	}
+
+	for _, r := range results {
+		name := serviceLabel(r.Target, r.Service)
+		switch {
+		case r.Error != "":
+			fmt.Printf("Health check failed for %s: %s\n", name, r.Error)
+		case !r.Healthy:
+			fmt.Printf("Service is not serving for %s: %s\n", name, r.Status)
+		}
+	}
+	if healthy {
+		fmt.Println("Service is healthy")
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+// newTLSConfig builds the client TLS configuration, or nil when TLS is disabled
+func newTLSConfig(enabled bool, caFile, serverName string, skipVerify bool) (*tls.Config, error) {
+	if !enabled {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: skipVerify,
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// dial creates a gRPC client connection. The connection is established
+// lazily, so failures surface as errors of the first RPC.
+func dial(addr string, tlsConfig *tls.Config, token string) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if token != "" {
+		opts = append(opts,
+			grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				return invoker(withToken(ctx, token), method, req, reply, cc, opts...)
+			}),
+			grpc.WithStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return streamer(withToken(ctx, token), desc, cc, method, opts...)
+			}),
+		)
+	}
+
+	return grpc.NewClient(addr, opts...)
+}
+
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+// checkGRPC performs a single health Check RPC
+func checkGRPC(ctx context.Context, conn *grpc.ClientConn, addr, service string, timeout time.Duration) result {
+	start := time.Now()
+	r := result{Target: addr, Service: service}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{
+		Service: service,
+	})
+	r.Duration = elapsedMS(start)
+	if err != nil {
+		r.Status = "UNKNOWN"
+		r.Error = err.Error()
+		return r
+	}
+
+	r.Status = resp.Status.String()
+	r.Healthy = resp.Status == grpc_health_v1.HealthCheckResponse_SERVING
+	return r
+}
+
+// watchGRPC streams health updates for a service and returns once its status
+// changes from the initial one, or when ctx expires. An expired ctx means
+// the status did not change, so the last status received stands; any other
+// stream error, such as the server going away, is unhealthy.
+func watchGRPC(ctx context.Context, conn *grpc.ClientConn, addr, service string) result {
+	start := time.Now()
+	r := result{Target: addr, Service: service, Status: "UNKNOWN"}
+
+	stream, err := grpc_health_v1.NewHealthClient(conn).Watch(ctx, &grpc_health_v1.HealthCheckRequest{
+		Service: service,
+	})
+	if err != nil {
+		r.Error = err.Error()
+		r.Duration = elapsedMS(start)
+		return r
+	}
+
+	var initial grpc_health_v1.HealthCheckResponse_ServingStatus = -1
+	for {
+		resp, err := stream.Recv()
+		r.Duration = elapsedMS(start)
+		if err != nil {
+			if initial != -1 && ctx.Err() == context.DeadlineExceeded {
+				return r
+			}
+			r.Status = "UNKNOWN"
+			r.Healthy = false
+			r.Error = err.Error()
+			return r
+		}
+
+		r.Status = resp.Status.String()
+		r.Healthy = resp.Status == grpc_health_v1.HealthCheckResponse_SERVING
+		fmt.Fprintf(os.Stderr, "%s %s: %s\n", time.Now().Format(time.RFC3339), serviceLabel(addr, service), r.Status)
+
+		if initial == -1 {
+			initial = resp.Status
+			continue
+		}
+		if resp.Status != initial {
+			return r
+		}
+	}
+}
+
+// checkHTTP probes an HTTP endpoint such as the gateway's /readyz, which is
+// healthy when it answers 2xx
+func checkHTTP(ctx context.Context, url, token string, tlsConfig *tls.Config, timeout time.Duration) result {
+	start := time.Now()
+	r := result{Target: url}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		r.Status = "UNKNOWN"
+		r.Error = err.Error()
+		return r
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	resp, err := client.Do(req)
+	r.Duration = elapsedMS(start)
+	if err != nil {
+		r.Status = "UNKNOWN"
+		r.Error = err.Error()
+		return r
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	r.Status = resp.Status
+	r.Healthy = resp.StatusCode >= 200 && resp.StatusCode < 300
+	return r
+}
+
+func serviceLabel(addr, service string) string {
+	if service == "" {
+		return addr
+	}
+	return addr + " (" + service + ")"
+}
+
+func elapsedMS(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/user"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/gateway"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/grpcweb"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/health"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/shutdown"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/swagger"
+)
+
+// singlePortServer serves native gRPC, gRPC-Web and the REST gateway on one
+// listener. Requests are routed by protocol and content type; without TLS,
+// cleartext HTTP/2 is accepted so native gRPC clients still work.
+type singlePortServer struct {
+	httpServer *http.Server
+	grpcServer *grpc.Server
+	// tls is set when httpServer terminates TLS. Serve sets up HTTP/2 and
+	// with it httpServer.TLSConfig, so that cannot tell.
+	tls bool
+
+	// active counts requests in flight. Connections upgraded to h2c are
+	// hijacked from the HTTP server, so its Shutdown does not wait for them.
+	active atomic.Int64
+}
+
+func newSinglePortServer(ctx context.Context, appCfg *config.Config, tlsConfig *tls.Config, cors *gateway.CORSPolicy, grpcServer *grpc.Server, userService pb.UserServiceServer, unary []grpc.UnaryServerInterceptor, checks *health.Registry) (*singlePortServer, error) {
+	cfg := appCfg.Gateway
+
+	// The gateway calls the service in-process, which bypasses the gRPC
+	// server, so the unary interceptors are applied by the adapter instead
+	muxOptions, err := gateway.ServeMuxOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	gwmux := runtime.NewServeMux(muxOptions...)
+	err = pb.RegisterUserServiceHandlerServer(ctx, gwmux, &inProcessUserService{
+		srv:         userService,
+		interceptor: chainUnaryInterceptors(unary),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", gwmux)
+	mux.Handle("/livez", checks.Handler(health.Liveness))
+	mux.Handle("/readyz", checks.Handler(health.Readiness))
+	spec, err := swagger.NewSpec(cfg.BasePath, cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	spec.Register(mux)
+
+	// gRPC-Web comes from browsers, so it passes through the HTTP middleware
+	// for CORS, security headers and access logs like REST requests do
+	grpcWeb := grpcweb.Handler(grpcServer)
+	httpHandler, err := gateway.Middleware(cfg, cors, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if grpcweb.IsGRPCWebRequest(r) {
+			grpcWeb.ServeHTTP(w, r)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &singlePortServer{grpcServer: grpcServer, tls: tlsConfig != nil}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.active.Add(1)
+		defer s.active.Add(-1)
+
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		httpHandler.ServeHTTP(w, r)
+	})
+	// gRPC's own connection settings do not apply to connections accepted
+	// by the HTTP server, so the equivalent HTTP/2 settings are used
+	server := appCfg.Server
+	h2Server := &http2.Server{
+		MaxConcurrentStreams: uint32(server.MaxConcurrentStreams),
+		IdleTimeout:          time.Duration(server.IdleTimeout) * time.Second,
+		ReadIdleTimeout:      time.Duration(server.Keepalive.Time) * time.Second,
+		PingTimeout:          time.Duration(server.Keepalive.Timeout) * time.Second,
+	}
+	s.httpServer = &http.Server{
+		Handler:           h2c.NewHandler(handler, h2Server),
+		ReadHeaderTimeout: time.Duration(server.ReadTimeout) * time.Second,
+		IdleTimeout:       time.Duration(server.IdleTimeout) * time.Second,
+		TLSConfig:         tlsConfig,
+	}
+	if tlsConfig != nil {
+		// HTTP/2 over TLS is negotiated by the HTTP server rather than h2c
+		if err := http2.ConfigureServer(s.httpServer, h2Server); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Serve accepts connections on lis until the server is shut down
+func (s *singlePortServer) Serve(lis net.Listener) error {
+	serve := s.httpServer.Serve
+	if s.tls {
+		// The certificate comes from TLSConfig.GetCertificate
+		serve = func(lis net.Listener) error { return s.httpServer.ServeTLS(lis, "", "") }
+	}
+	if err := serve(lis); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Drain stops accepting connections and waits for in-flight requests. The
+// gRPC server is stopped rather than gracefully stopped because streams
+// served through ServeHTTP do not support GracefulStop.
+func (s *singlePortServer) Drain(ctx context.Context) error {
+	defer s.grpcServer.Stop()
+
+	if err := shutdown.HTTPServer(s.httpServer)(ctx); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for s.active.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// chainUnaryInterceptors combines interceptors into one, the first being the
+// outermost, matching grpc.ChainUnaryInterceptor
+func chainUnaryInterceptors(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		chained := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], chained
+			chained = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return chained(ctx, req)
+	}
+}
+
+// inProcessUserService runs gateway calls through the unary interceptors
+// before they reach the user service. WatchUsers is left unimplemented as
+// the in-process gateway does not support streaming; it is available over
+// gRPC and gRPC-Web.
+type inProcessUserService struct {
+	pb.UnimplementedUserServiceServer
+	srv         pb.UserServiceServer
+	interceptor grpc.UnaryServerInterceptor
+}
+
+// CreateUser implements pb.UserServiceServer
+func (s *inProcessUserService) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.UserResponse, error) {
+	return invoke(ctx, s, pb.UserService_CreateUser_FullMethodName, req, s.srv.CreateUser)
+}
+
+// GetUser implements pb.UserServiceServer
+func (s *inProcessUserService) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.UserResponse, error) {
+	return invoke(ctx, s, pb.UserService_GetUser_FullMethodName, req, s.srv.GetUser)
+}
+
+// ListUsers implements pb.UserServiceServer
+func (s *inProcessUserService) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	return invoke(ctx, s, pb.UserService_ListUsers_FullMethodName, req, s.srv.ListUsers)
+}
+
+// UpdateUser implements pb.UserServiceServer
+func (s *inProcessUserService) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UserResponse, error) {
+	return invoke(ctx, s, pb.UserService_UpdateUser_FullMethodName, req, s.srv.UpdateUser)
+}
+
+// DeleteUser implements pb.UserServiceServer
+func (s *inProcessUserService) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*emptypb.Empty, error) {
+	return invoke(ctx, s, pb.UserService_DeleteUser_FullMethodName, req, s.srv.DeleteUser)
+}
+
+// SearchUsers implements pb.UserServiceServer
+func (s *inProcessUserService) SearchUsers(ctx context.Context, req *pb.SearchUsersRequest) (*pb.SearchUsersResponse, error) {
+	return invoke(ctx, s, pb.UserService_SearchUsers_FullMethodName, req, s.srv.SearchUsers)
+}
+
+// invoke calls method through the interceptor chain
+func invoke[Req, Resp any](ctx context.Context, s *inProcessUserService, method string, req Req, call func(context.Context, Req) (Resp, error)) (Resp, error) {
+	info := &grpc.UnaryServerInfo{Server: s.srv, FullMethod: method}
+	resp, err := s.interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return call(ctx, req.(Req))
+	})
+	if err != nil {
+		var zero Resp
+		return zero, err
+	}
+	out, _ := resp.(Resp)
+	return out, nil
+}
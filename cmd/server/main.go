@@ -2,28 +2,42 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"os"
-	"os/signal"
+	"path/filepath"
+	"strconv"
 	"syscall"
 	"time"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health/grpc_health_v1"
-	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 
-	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/user"
+	adminpb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/admin"
+	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/user"
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/internal/handler"
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/db"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/gateway"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/health"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/listener"
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/metrics"
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/middleware"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/shutdown"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/tlscert"
 )
 
 func main() {
+	// "server config validate|print" inspects the configuration and exits
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(config.RunCommand("server", os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -32,105 +46,180 @@ func main() {
 	}
 
 	// Initialize logger
-	logger.Init(cfg.App.Environment)
+	if err := logger.Setup(cfg); err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
 	defer logger.Sync()
 
 	// Log basic information
-	logger.Info("Starting service",
-		zap.String("app", cfg.App.Name),
-		zap.String("environment", cfg.App.Environment),
-	)
+	logger.Info("Starting service")
 
-	// Start Prometheus metrics server
-	metrics.StartMetricsServer(9100)
+	// Load the TLS certificate, if TLS is enabled
+	var certificate *tlscert.Certificate
+	var tlsConfig *tls.Config
+	if cfg.Server.TLS.Enabled {
+		certificate, err = tlscert.New(cfg.Server.TLS)
+		if err != nil {
+			logger.Fatal("Failed to load TLS certificate", zap.Error(err))
+		}
+		tlsConfig = certificate.ServerConfig()
+		logger.Info("TLS enabled", zap.Time("certificate_expires", certificate.NotAfter()))
+	}
 
-	// Create gRPC server with interceptors
-	grpcServer := grpc.NewServer(
-		grpc.KeepaliveParams(keepalive.ServerParameters{
-			MaxConnectionIdle:     time.Duration(cfg.Server.IdleTimeout) * time.Second,
-			MaxConnectionAge:      time.Hour,
-			MaxConnectionAgeGrace: time.Minute * 5,
-			Time:                  time.Minute,
-			Timeout:               time.Second * 20,
-		}),
-		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
-			MinTime:             time.Second * 5,
-			PermitWithoutStream: true,
-		}),
-		grpc.ChainUnaryInterceptor(
-			middleware.RecoveryInterceptor(),
-			middleware.LoggingInterceptor(),
-			// Uncomment when you have authentication set up
-			// middleware.AuthInterceptor(),
-			middleware.RateLimitInterceptor(),
-		),
+	// The log level, authentication, rate limits and TLS certificate follow
+	// changes to the config file and secret files; other changes need a
+	// restart
+	authPolicy := middleware.NewAuthPolicy(cfg.Server.Auth)
+	rateLimiter := middleware.NewRateLimiter(cfg.Server.RateLimit)
+	corsPolicy := gateway.NewCORSPolicy(cfg.Gateway.CORS)
+	if watcher := logger.WatchConfig(cfg); watcher != nil {
+		watcher.Subscribe(func(old, updated *config.Config) {
+			authPolicy.Update(updated.Server.Auth)
+			rateLimiter.Update(updated.Server.RateLimit)
+			corsPolicy.Update(updated.Gateway.CORS)
+			if certificate != nil && updated.Server.TLS != old.Server.TLS {
+				if err := certificate.Update(updated.Server.TLS); err != nil {
+					logger.Error("Failed to reload TLS certificate", zap.Error(err))
+					return
+				}
+				logger.Info("TLS certificate reloaded", zap.Time("certificate_expires", certificate.NotAfter()))
+			}
+		})
+	}
+
+	// Create gRPC server with interceptors. The unary chain is kept so the
+	// in-process REST gateway can apply it as well in single-port mode.
+	deadlines := middleware.NewDeadlines(cfg.Server)
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		middleware.RecoveryInterceptor(),
+		middleware.DeadlineInterceptor(deadlines),
+		middleware.LoggingInterceptor(middleware.NewPayloadPolicy(cfg.Logging)),
+		middleware.AuthInterceptor(authPolicy),
+		middleware.RateLimitInterceptor(rateLimiter),
+	}
+	// In single-port mode the HTTP server terminates TLS instead
+	opts := serverOptions(cfg.Server)
+	if tlsConfig != nil && !cfg.Server.SinglePort {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(append(opts,
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
 		grpc.ChainStreamInterceptor(
 			middleware.RecoveryStreamInterceptor(),
+			middleware.DeadlineStreamInterceptor(deadlines),
 			middleware.LoggingStreamInterceptor(),
-			// Uncomment when you have authentication set up
-			// middleware.AuthStreamInterceptor(),
-			middleware.RateLimitStreamInterceptor(),
+			middleware.AuthStreamInterceptor(authPolicy),
+			middleware.RateLimitStreamInterceptor(rateLimiter),
 		),
-	)
+	)...)
 
-	// Create TCP listener
-	port := cfg.Server.Port
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
-	if err != nil {
-		logger.Fatal("Failed to listen", zap.Error(err))
+	// Create listeners, on host:port unless listen addresses are configured
+	addrs := cfg.Server.Listen
+	if len(addrs) == 0 {
+		addrs = []string{net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port))}
+	}
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		lis, err := listener.Listen(addr)
+		if err != nil {
+			logger.Fatal("Failed to listen", zap.String("address", addr), zap.Error(err))
+		}
+		listeners = append(listeners, lis)
 	}
 
 	// Create health check service
 	healthHandler := handler.NewHealthHandler()
-	healthHandler.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
 	grpc_health_v1.RegisterHealthServer(grpcServer, healthHandler)
 
 	// Register service handlers
 	userHandler := handler.NewUserHandler()
 	pb.RegisterUserServiceServer(grpcServer, userHandler)
+	adminpb.RegisterAdminServiceServer(grpcServer, handler.NewAdminHandler())
 	
 	// Enable reflection for tools like grpcurl
 	reflection.Register(grpcServer)
 
-	// Set service as serving
-	healthHandler.SetServingStatus(cfg.App.Name, grpc_health_v1.HealthCheckResponse_SERVING)
+	// Run dependency checks; their results drive the gRPC health status.
+	// Readiness covers the overall server and the application service name.
+	// The database is a dependency, so it is only checked for readiness: an
+	// outage should take the server out of rotation, not restart it.
+	checks := health.NewRegistry(
+		time.Duration(cfg.Health.CheckInterval)*time.Second,
+		time.Duration(cfg.Health.CheckTimeout)*time.Second,
+	)
+	checks.Register(health.Readiness, health.CheckFunc("sqlite", userHandler.Ping))
+	checks.Register(health.Readiness, health.CheckFunc("sqlite_write", userHandler.ProbeWrite))
+	checks.Register(health.Readiness, health.DiskSpace("disk",
+		filepath.Dir(db.GetSQLiteDBPath()),
+		uint64(cfg.Health.MinFreeDiskMB)*1024*1024,
+	))
+	checks.OnChange(func(probe health.Probe, healthy bool) {
+		servingStatus := grpc_health_v1.HealthCheckResponse_NOT_SERVING
+		if healthy {
+			servingStatus = grpc_health_v1.HealthCheckResponse_SERVING
+		}
+		healthHandler.SetServingStatus(string(probe), servingStatus)
+		if probe == health.Readiness {
+			healthHandler.SetServingStatus("", servingStatus)
+			healthHandler.SetServingStatus(cfg.App.Name, servingStatus)
+		}
+	})
+	checksCtx, stopChecks := context.WithCancel(context.Background())
+	defer stopChecks()
+	checks.Start(checksCtx)
+
+	// Start Prometheus metrics server with Kubernetes probe endpoints
+	metrics.Handle("/livez", checks.Handler(health.Liveness))
+	metrics.Handle("/readyz", checks.Handler(health.Readiness))
+	metrics.StartMetricsServer(9100)
+
+	// Shut down in phases: report NOT_SERVING, give load balancers time to
+	// notice, drain in-flight requests up to the deadline, then release
+	// resources
+	coordinator := shutdown.New(
+		time.Duration(cfg.Shutdown.PreStopDelay)*time.Second,
+		time.Duration(cfg.Shutdown.DrainTimeout)*time.Second,
+	)
+	coordinator.OnNotServing(func() {
+		stopChecks()
+		checks.Shutdown()
+		healthHandler.Shutdown()
+	})
 
 	// Start server in a goroutine
-	go func() {
-		logger.Info("Server listening", zap.Int("port", port))
-		if err := grpcServer.Serve(lis); err != nil {
-			logger.Fatal("Failed to serve", zap.Error(err))
+	if cfg.Server.SinglePort {
+		server, err := newSinglePortServer(checksCtx, cfg, tlsConfig, corsPolicy, grpcServer, userHandler, unaryInterceptors, checks)
+		if err != nil {
+			logger.Fatal("Failed to set up gateway", zap.Error(err))
+		}
+		coordinator.OnDrain("server", server.Drain)
+		for _, lis := range listeners {
+			go func(lis net.Listener) {
+				logger.Info("Server listening (gRPC, gRPC-Web and REST)", zap.String("address", listener.URL(lis)))
+				if err := server.Serve(lis); err != nil {
+					logger.Fatal("Failed to serve", zap.Error(err))
+				}
+			}(lis)
 		}
-	}()
+	} else {
+		coordinator.OnDrain("grpc", shutdown.GRPCServer(grpcServer))
+		for _, lis := range listeners {
+			go func(lis net.Listener) {
+				logger.Info("Server listening", zap.String("address", listener.URL(lis)))
+				if err := grpcServer.Serve(lis); err != nil {
+					logger.Fatal("Failed to serve", zap.Error(err))
+				}
+			}(lis)
+		}
+	}
 
-	// Graceful shutdown
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
-	<-sigCh
+	coordinator.OnClose("database", userHandler.Close)
+	coordinator.OnClose("logger", func() error {
+		logger.Info("Server stopped")
+		logger.Sync()
+		return nil
+	})
 
-	logger.Info("Shutting down server...")
-	
-	// Set service to NOT_SERVING during shutdown
-	healthHandler.SetServingStatus(cfg.App.Name, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
-	
-	// Stop accepting new requests
-	grpcServer.GracefulStop()
-	
-	// Close database connection
-	if err := userHandler.Close(); err != nil {
-		logger.Error("Error closing DB connection", zap.Error(err))
-	}
-	
-	// Allow some time for existing requests to complete
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	
-	select {
-	case <-ctx.Done():
-		logger.Info("Timeout waiting for connections to close, forcing shutdown")
-	case <-time.After(10 * time.Millisecond):
-		logger.Info("All connections closed successfully")
-	}
-	
-	logger.Info("Server stopped")
-} 
\ No newline at end of file
+	coordinator.Wait(os.Interrupt, syscall.SIGTERM)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+// serverOptions returns the gRPC server's connection, keepalive and message
+// size options. Zero values keep gRPC's defaults.
+func serverOptions(cfg config.ServerConfig) []grpc.ServerOption {
+	seconds := func(n int) time.Duration { return time.Duration(n) * time.Second }
+
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     seconds(cfg.IdleTimeout),
+			MaxConnectionAge:      seconds(cfg.Keepalive.MaxConnectionAge),
+			MaxConnectionAgeGrace: seconds(cfg.Keepalive.MaxConnectionAgeGrace),
+			Time:                  seconds(cfg.Keepalive.Time),
+			Timeout:               seconds(cfg.Keepalive.Timeout),
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             seconds(cfg.Keepalive.MinTime),
+			PermitWithoutStream: cfg.Keepalive.PermitWithoutStream,
+		}),
+	}
+	if cfg.ReadTimeout > 0 {
+		opts = append(opts, grpc.ConnectionTimeout(seconds(cfg.ReadTimeout)))
+	}
+	if cfg.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(uint32(cfg.MaxConcurrentStreams)))
+	}
+	if cfg.MaxRecvMsgSizeBytes > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSizeBytes))
+	}
+	if cfg.MaxSendMsgSizeBytes > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(cfg.MaxSendMsgSizeBytes))
+	}
+	return opts
+}
@@ -11,6 +11,8 @@ import (
 	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -23,6 +25,58 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type UserEvent_Type int32
+
+const (
+	UserEvent_TYPE_UNSPECIFIED UserEvent_Type = 0
+	UserEvent_CREATED          UserEvent_Type = 1
+	UserEvent_UPDATED          UserEvent_Type = 2
+	UserEvent_DELETED          UserEvent_Type = 3
+)
+
+// Enum value maps for UserEvent_Type.
+var (
+	UserEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "CREATED",
+		2: "UPDATED",
+		3: "DELETED",
+	}
+	UserEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"CREATED":          1,
+		"UPDATED":          2,
+		"DELETED":          3,
+	}
+)
+
+func (x UserEvent_Type) Enum() *UserEvent_Type {
+	p := new(UserEvent_Type)
+	*p = x
+	return p
+}
+
+func (x UserEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (UserEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_user_proto_enumTypes[0].Descriptor()
+}
+
+func (UserEvent_Type) Type() protoreflect.EnumType {
+	return &file_api_user_proto_enumTypes[0]
+}
+
+func (x UserEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use UserEvent_Type.Descriptor instead.
+func (UserEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{9, 0}
+}
+
 type CreateUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -119,18 +173,437 @@ func (x *GetUserRequest) GetId() string {
 	return ""
 }
 
+type ListUsersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsersRequest) Reset() {
+	*x = ListUsersRequest{}
+	mi := &file_api_user_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersRequest) ProtoMessage() {}
+
+func (x *ListUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_user_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersRequest.ProtoReflect.Descriptor instead.
+func (*ListUsersRequest) Descriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListUsersRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListUsersRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListUsersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*UserResponse        `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsersResponse) Reset() {
+	*x = ListUsersResponse{}
+	mi := &file_api_user_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersResponse) ProtoMessage() {}
+
+func (x *ListUsersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_user_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersResponse.ProtoReflect.Descriptor instead.
+func (*ListUsersResponse) Descriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListUsersResponse) GetUsers() []*UserResponse {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+func (x *ListUsersResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type UpdateUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateUserRequest) Reset() {
+	*x = UpdateUserRequest{}
+	mi := &file_api_user_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateUserRequest) ProtoMessage() {}
+
+func (x *UpdateUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_user_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateUserRequest.ProtoReflect.Descriptor instead.
+func (*UpdateUserRequest) Descriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UpdateUserRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateUserRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateUserRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type DeleteUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteUserRequest) Reset() {
+	*x = DeleteUserRequest{}
+	mi := &file_api_user_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserRequest) ProtoMessage() {}
+
+func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_user_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteUserRequest.ProtoReflect.Descriptor instead.
+func (*DeleteUserRequest) Descriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeleteUserRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type SearchUsersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string                 `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchUsersRequest) Reset() {
+	*x = SearchUsersRequest{}
+	mi := &file_api_user_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchUsersRequest) ProtoMessage() {}
+
+func (x *SearchUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_user_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchUsersRequest.ProtoReflect.Descriptor instead.
+func (*SearchUsersRequest) Descriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SearchUsersRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchUsersRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *SearchUsersRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type SearchUsersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*UserResponse        `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchUsersResponse) Reset() {
+	*x = SearchUsersResponse{}
+	mi := &file_api_user_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchUsersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchUsersResponse) ProtoMessage() {}
+
+func (x *SearchUsersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_user_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchUsersResponse.ProtoReflect.Descriptor instead.
+func (*SearchUsersResponse) Descriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SearchUsersResponse) GetUsers() []*UserResponse {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+func (x *SearchUsersResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type WatchUsersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchUsersRequest) Reset() {
+	*x = WatchUsersRequest{}
+	mi := &file_api_user_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchUsersRequest) ProtoMessage() {}
+
+func (x *WatchUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_user_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchUsersRequest.ProtoReflect.Descriptor instead.
+func (*WatchUsersRequest) Descriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{8}
+}
+
+// UserEvent describes a change to a user
+type UserEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          UserEvent_Type         `protobuf:"varint,1,opt,name=type,proto3,enum=user.UserEvent_Type" json:"type,omitempty"`
+	User          *UserResponse          `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserEvent) Reset() {
+	*x = UserEvent{}
+	mi := &file_api_user_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserEvent) ProtoMessage() {}
+
+func (x *UserEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_user_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserEvent.ProtoReflect.Descriptor instead.
+func (*UserEvent) Descriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UserEvent) GetType() UserEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return UserEvent_TYPE_UNSPECIFIED
+}
+
+func (x *UserEvent) GetUser() *UserResponse {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *UserEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
 type UserResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
 	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UserResponse) Reset() {
 	*x = UserResponse{}
-	mi := &file_api_user_proto_msgTypes[2]
+	mi := &file_api_user_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -142,7 +615,7 @@ func (x *UserResponse) String() string {
 func (*UserResponse) ProtoMessage() {}
 
 func (x *UserResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_user_proto_msgTypes[2]
+	mi := &file_api_user_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -155,7 +628,7 @@ func (x *UserResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserResponse.ProtoReflect.Descriptor instead.
 func (*UserResponse) Descriptor() ([]byte, []int) {
-	return file_api_user_proto_rawDescGZIP(), []int{2}
+	return file_api_user_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *UserResponse) GetId() string {
@@ -179,31 +652,267 @@ func (x *UserResponse) GetEmail() string {
 	return ""
 }
 
+func (x *UserResponse) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *UserResponse) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+// Problem is the RFC 7807 error body returned by the REST gateway as
+// application/problem+json. It is not used by the gRPC API.
+type Problem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Status        int32                  `protobuf:"varint,3,opt,name=status,proto3" json:"status,omitempty"`
+	Detail        string                 `protobuf:"bytes,4,opt,name=detail,proto3" json:"detail,omitempty"`
+	Instance      string                 `protobuf:"bytes,5,opt,name=instance,proto3" json:"instance,omitempty"`
+	Code          string                 `protobuf:"bytes,6,opt,name=code,proto3" json:"code,omitempty"`
+	RequestId     string                 `protobuf:"bytes,7,opt,name=request_id,proto3" json:"request_id,omitempty"`
+	InvalidParams []*InvalidParam        `protobuf:"bytes,8,rep,name=invalid_params,proto3" json:"invalid_params,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Problem) Reset() {
+	*x = Problem{}
+	mi := &file_api_user_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Problem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Problem) ProtoMessage() {}
+
+func (x *Problem) ProtoReflect() protoreflect.Message {
+	mi := &file_api_user_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Problem.ProtoReflect.Descriptor instead.
+func (*Problem) Descriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Problem) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Problem) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Problem) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *Problem) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+func (x *Problem) GetInstance() string {
+	if x != nil {
+		return x.Instance
+	}
+	return ""
+}
+
+func (x *Problem) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Problem) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *Problem) GetInvalidParams() []*InvalidParam {
+	if x != nil {
+		return x.InvalidParams
+	}
+	return nil
+}
+
+// InvalidParam describes a request field that failed validation
+type InvalidParam struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InvalidParam) Reset() {
+	*x = InvalidParam{}
+	mi := &file_api_user_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InvalidParam) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InvalidParam) ProtoMessage() {}
+
+func (x *InvalidParam) ProtoReflect() protoreflect.Message {
+	mi := &file_api_user_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InvalidParam.ProtoReflect.Descriptor instead.
+func (*InvalidParam) Descriptor() ([]byte, []int) {
+	return file_api_user_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *InvalidParam) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *InvalidParam) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
 var File_api_user_proto protoreflect.FileDescriptor
 
 const file_api_user_proto_rawDesc = "" +
 	"\n" +
-	"\x0eapi/user.proto\x12\x04user\x1a\x1cgoogle/api/annotations.proto\x1a.protoc-gen-openapiv2/options/annotations.proto\"\x96\x01\n" +
+	"\x0eapi/user.proto\x12\x04user\x1a\x1cgoogle/api/annotations.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a.protoc-gen-openapiv2/options/annotations.proto\"\x96\x01\n" +
 	"\x11CreateUserRequest\x124\n" +
 	"\x04name\x18\x01 \x01(\tB \x92A\x1d2\x0fThe user's nameJ\n" +
 	"\"John Doe\"R\x04name\x12K\n" +
 	"\x05email\x18\x02 \x01(\tB5\x92A22\x18The user's email addressJ\x16\"john.doe@example.com\"R\x05email\"\\\n" +
 	"\x0eGetUserRequest\x12J\n" +
-	"\x02id\x18\x01 \x01(\tB:\x92A72\rThe user's IDJ&\"6ba7b810-9dad-11d1-80b4-00c04fd430c8\"R\x02id\"\x98\x01\n" +
+	"\x02id\x18\x01 \x01(\tB:\x92A72\rThe user's IDJ&\"6ba7b810-9dad-11d1-80b4-00c04fd430c8\"R\x02id\"\xd6\x01\n" +
+	"\x10ListUsersRequest\x12g\n" +
+	"\tpage_size\x18\x01 \x01(\x05BJ\x92AG2AMaximum number of users to return, 50 by default and at most 1000J\x0250R\bpageSize\x12Y\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tB:\x92A725Token from a previous response to fetch the next pageR\tpageToken\"\x9b\x01\n" +
+	"\x11ListUsersResponse\x12(\n" +
+	"\x05users\x18\x01 \x03(\v2\x12.user.UserResponseR\x05users\x12\\\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tB4\x92A12/Token for the next page, empty on the last pageR\rnextPageToken\"\x84\x02\n" +
+	"\x11UpdateUserRequest\x12J\n" +
+	"\x02id\x18\x01 \x01(\tB:\x92A72\rThe user's IDJ&\"6ba7b810-9dad-11d1-80b4-00c04fd430c8\"R\x02id\x12E\n" +
+	"\x04name\x18\x02 \x01(\tB1\x92A.2 The new name, unchanged if emptyJ\n" +
+	"\"Jane Doe\"R\x04name\x12\\\n" +
+	"\x05email\x18\x03 \x01(\tBF\x92AC2)The new email address, unchanged if emptyJ\x16\"jane.doe@example.com\"R\x05email\"_\n" +
+	"\x11DeleteUserRequest\x12J\n" +
+	"\x02id\x18\x01 \x01(\tB:\x92A72\rThe user's IDJ&\"6ba7b810-9dad-11d1-80b4-00c04fd430c8\"R\x02id\"\xa9\x02\n" +
+	"\x12SearchUsersRequest\x12O\n" +
+	"\x05query\x18\x01 \x01(\tB9\x92A62-Text to look for in names and email addressesJ\x05\"doe\"R\x05query\x12g\n" +
+	"\tpage_size\x18\x02 \x01(\x05BJ\x92AG2AMaximum number of users to return, 50 by default and at most 1000J\x0250R\bpageSize\x12Y\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tB:\x92A725Token from a previous response to fetch the next pageR\tpageToken\"\x9d\x01\n" +
+	"\x13SearchUsersResponse\x12(\n" +
+	"\x05users\x18\x01 \x03(\v2\x12.user.UserResponseR\x05users\x12\\\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tB4\x92A12/Token for the next page, empty on the last pageR\rnextPageToken\"\x13\n" +
+	"\x11WatchUsersRequest\"\xb3\x02\n" +
+	"\tUserEvent\x12(\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x14.user.UserEvent.TypeR\x04type\x12h\n" +
+	"\x04user\x18\x02 \x01(\v2\x12.user.UserResponseB@\x92A=2;The user after the change; only the ID is set for deletionsR\x04user\x12M\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampB\x1d\x92A\x1a2\x18When the change happenedR\x04time\"C\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\v\n" +
+	"\aCREATED\x10\x01\x12\v\n" +
+	"\aUPDATED\x10\x02\x12\v\n" +
+	"\aDELETED\x10\x03\"\xd3\x02\n" +
 	"\fUserResponse\x12)\n" +
 	"\x02id\x18\x01 \x01(\tB\x19\x92A\x162\x14The user's unique IDR\x02id\x12(\n" +
 	"\x04name\x18\x02 \x01(\tB\x14\x92A\x112\x0fThe user's nameR\x04name\x123\n" +
-	"\x05email\x18\x03 \x01(\tB\x1d\x92A\x1a2\x18The user's email addressR\x05email2\xaa\x02\n" +
+	"\x05email\x18\x03 \x01(\tB\x1d\x92A\x1a2\x18The user's email addressR\x05email\x12Y\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampB\x1e\x92A\x1b2\x19When the user was createdR\tcreatedAt\x12^\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampB#\x92A 2\x1eWhen the user was last updatedR\tupdatedAt\"\xa4\x06\n" +
+	"\aProblem\x12\x9c\x01\n" +
+	"\x04type\x18\x01 \x01(\tB\x87\x01\x92A\x83\x012 URI identifying the problem typeJ_\"https://github.com/Akashdeep-Patra/go-grpc-sqlite/blob/main/docs/problems.md#invalid-argument\"R\x04type\x12q\n" +
+	"\x05title\x18\x02 \x01(\tB[\x92AX2BShort summary of the problem type, localised using Accept-LanguageJ\x12\"Invalid argument\"R\x05title\x122\n" +
+	"\x06status\x18\x03 \x01(\x05B\x1a\x92A\x172\x10HTTP status codeJ\x03400R\x06status\x12h\n" +
+	"\x06detail\x18\x04 \x01(\tBP\x92AM26Explanation specific to this occurrence of the problemJ\x13\"email is required\"R\x06detail\x12Y\n" +
+	"\binstance\x18\x05 \x01(\tB=\x92A:2+Path of the request that caused the problemJ\v\"/v1/users\"R\binstance\x12B\n" +
+	"\x04code\x18\x06 \x01(\tB.\x92A+2\x15gRPC status code nameJ\x12\"INVALID_ARGUMENT\"R\x04code\x12b\n" +
+	"\n" +
+	"request_id\x18\a \x01(\tBB\x92A?2=ID of the request, echoed in the X-Request-Id response headerR\n" +
+	"request_id\x12f\n" +
+	"\x0einvalid_params\x18\b \x03(\v2\x12.user.InvalidParamB*\x92A'2%Request fields that failed validationR\x0einvalid_params\"\x97\x01\n" +
+	"\fInvalidParam\x12;\n" +
+	"\x04name\x18\x01 \x01(\tB'\x92A$2\x19Name of the invalid fieldJ\a\"email\"R\x04name\x12J\n" +
+	"\x06reason\x18\x02 \x01(\tB2\x92A/2\x18Why the field is invalidJ\x13\"email is required\"R\x06reason2\xdb\b\n" +
 	"\vUserService\x12\xa1\x01\n" +
 	"\n" +
 	"CreateUser\x12\x17.user.CreateUserRequest\x1a\x12.user.UserResponse\"f\x92AO\n" +
 	"\x05Users\x12\x11Create a new user\x1a3Creates a new user with the provided name and email\x82\xd3\xe4\x93\x02\x0e:\x01*\"\t/v1/users\x12w\n" +
 	"\aGetUser\x12\x14.user.GetUserRequest\x1a\x12.user.UserResponse\"B\x92A)\n" +
 	"\x05Users\x12\n" +
-	"Get a user\x1a\x14Returns a user by ID\x82\xd3\xe4\x93\x02\x10\x12\x0e/v1/users/{id}B\xe1\x01\x92A\xad\x01\x12\x82\x01\n" +
+	"Get a user\x1a\x14Returns a user by ID\x82\xd3\xe4\x93\x02\x10\x12\x0e/v1/users/{id}\x12\x97\x01\n" +
+	"\tListUsers\x12\x16.user.ListUsersRequest\x1a\x17.user.ListUsersResponse\"Y\x92AE\n" +
+	"\x05Users\x12\n" +
+	"List users\x1a0Returns a page of users ordered by creation time\x82\xd3\xe4\x93\x02\v\x12\t/v1/users\x12\xb7\x01\n" +
+	"\n" +
+	"UpdateUser\x12\x17.user.UpdateUserRequest\x1a\x12.user.UserResponse\"|\x92A`\n" +
+	"\x05Users\x12\rUpdate a user\x1aHUpdates the name and/or email of a user; empty fields are left unchanged\x82\xd3\xe4\x93\x02\x13:\x01*2\x0e/v1/users/{id}\x12\x84\x01\n" +
+	"\n" +
+	"DeleteUser\x12\x17.user.DeleteUserRequest\x1a\x16.google.protobuf.Empty\"E\x92A,\n" +
+	"\x05Users\x12\rDelete a user\x1a\x14Deletes a user by ID\x82\xd3\xe4\x93\x02\x10*\x0e/v1/users/{id}\x12\xc3\x01\n" +
+	"\vSearchUsers\x12\x18.user.SearchUsersRequest\x1a\x19.user.SearchUsersResponse\"\x7f\x92Ad\n" +
+	"\x05Users\x12\fSearch users\x1aMReturns a page of users whose name or email contains the query, ignoring case\x82\xd3\xe4\x93\x02\x12\x12\x10/v1/users:search\x12\x8d\x01\n" +
+	"\n" +
+	"WatchUsers\x12\x17.user.WatchUsersRequest\x1a\x0f.user.UserEvent\"S\x92A9\n" +
+	"\x05Users\x12\vWatch users\x1a#Streams user changes as they happen\x82\xd3\xe4\x93\x02\x11\x12\x0f/v1/users:watch0\x01B\xc5\x02\x92A\x91\x02\x12\x82\x01\n" +
 	"\x10User Service API\x12\x16API for managing users\"J\n" +
 	"\x0fAkashdeep Patra\x12\"https://github.com/Akashdeep-Patra\x1a\x13adeep8961@gmail.com*\x05\n" +
-	"\x03MIT2\x031.0*\x02\x01\x022\x10application/json:\x10application/jsonZ.github.com/Akashdeep-Patra/go-grpc-sqlite/userb\x06proto3"
+	"\x03MIT2\x031.0*\x02\x01\x022\x10application/json:\x10application/json:\x18application/problem+jsonRH\n" +
+	"\adefault\x12=\n" +
+	"(An RFC 7807 problem describing the error\x12\x11\n" +
+	"\x0f\x1a\r.user.ProblemZ.github.com/Akashdeep-Patra/go-grpc-sqlite/userb\x06proto3"
 
 var (
 	file_api_user_proto_rawDescOnce sync.Once
@@ -217,22 +926,54 @@ func file_api_user_proto_rawDescGZIP() []byte {
 	return file_api_user_proto_rawDescData
 }
 
-var file_api_user_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_api_user_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_api_user_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_api_user_proto_goTypes = []any{
-	(*CreateUserRequest)(nil), // 0: user.CreateUserRequest
-	(*GetUserRequest)(nil),    // 1: user.GetUserRequest
-	(*UserResponse)(nil),      // 2: user.UserResponse
+	(UserEvent_Type)(0),           // 0: user.UserEvent.Type
+	(*CreateUserRequest)(nil),     // 1: user.CreateUserRequest
+	(*GetUserRequest)(nil),        // 2: user.GetUserRequest
+	(*ListUsersRequest)(nil),      // 3: user.ListUsersRequest
+	(*ListUsersResponse)(nil),     // 4: user.ListUsersResponse
+	(*UpdateUserRequest)(nil),     // 5: user.UpdateUserRequest
+	(*DeleteUserRequest)(nil),     // 6: user.DeleteUserRequest
+	(*SearchUsersRequest)(nil),    // 7: user.SearchUsersRequest
+	(*SearchUsersResponse)(nil),   // 8: user.SearchUsersResponse
+	(*WatchUsersRequest)(nil),     // 9: user.WatchUsersRequest
+	(*UserEvent)(nil),             // 10: user.UserEvent
+	(*UserResponse)(nil),          // 11: user.UserResponse
+	(*Problem)(nil),               // 12: user.Problem
+	(*InvalidParam)(nil),          // 13: user.InvalidParam
+	(*timestamppb.Timestamp)(nil), // 14: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 15: google.protobuf.Empty
 }
 var file_api_user_proto_depIdxs = []int32{
-	0, // 0: user.UserService.CreateUser:input_type -> user.CreateUserRequest
-	1, // 1: user.UserService.GetUser:input_type -> user.GetUserRequest
-	2, // 2: user.UserService.CreateUser:output_type -> user.UserResponse
-	2, // 3: user.UserService.GetUser:output_type -> user.UserResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	11, // 0: user.ListUsersResponse.users:type_name -> user.UserResponse
+	11, // 1: user.SearchUsersResponse.users:type_name -> user.UserResponse
+	0,  // 2: user.UserEvent.type:type_name -> user.UserEvent.Type
+	11, // 3: user.UserEvent.user:type_name -> user.UserResponse
+	14, // 4: user.UserEvent.time:type_name -> google.protobuf.Timestamp
+	14, // 5: user.UserResponse.created_at:type_name -> google.protobuf.Timestamp
+	14, // 6: user.UserResponse.updated_at:type_name -> google.protobuf.Timestamp
+	13, // 7: user.Problem.invalid_params:type_name -> user.InvalidParam
+	1,  // 8: user.UserService.CreateUser:input_type -> user.CreateUserRequest
+	2,  // 9: user.UserService.GetUser:input_type -> user.GetUserRequest
+	3,  // 10: user.UserService.ListUsers:input_type -> user.ListUsersRequest
+	5,  // 11: user.UserService.UpdateUser:input_type -> user.UpdateUserRequest
+	6,  // 12: user.UserService.DeleteUser:input_type -> user.DeleteUserRequest
+	7,  // 13: user.UserService.SearchUsers:input_type -> user.SearchUsersRequest
+	9,  // 14: user.UserService.WatchUsers:input_type -> user.WatchUsersRequest
+	11, // 15: user.UserService.CreateUser:output_type -> user.UserResponse
+	11, // 16: user.UserService.GetUser:output_type -> user.UserResponse
+	4,  // 17: user.UserService.ListUsers:output_type -> user.ListUsersResponse
+	11, // 18: user.UserService.UpdateUser:output_type -> user.UserResponse
+	15, // 19: user.UserService.DeleteUser:output_type -> google.protobuf.Empty
+	8,  // 20: user.UserService.SearchUsers:output_type -> user.SearchUsersResponse
+	10, // 21: user.UserService.WatchUsers:output_type -> user.UserEvent
+	15, // [15:22] is the sub-list for method output_type
+	8,  // [8:15] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_api_user_proto_init() }
@@ -245,13 +986,14 @@ func file_api_user_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_user_proto_rawDesc), len(file_api_user_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   3,
+			NumEnums:      1,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_api_user_proto_goTypes,
 		DependencyIndexes: file_api_user_proto_depIdxs,
+		EnumInfos:         file_api_user_proto_enumTypes,
 		MessageInfos:      file_api_user_proto_msgTypes,
 	}.Build()
 	File_api_user_proto = out.File
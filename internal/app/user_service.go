@@ -2,20 +2,29 @@ package app
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/internal/domain"
 	"github.com/google/uuid"
 )
 
+// watchBuffer is the number of events a watcher may fall behind before it
+// is disconnected
+const watchBuffer = 64
+
 type userService struct {
 	repo domain.UserRepository
+
+	mu       sync.Mutex
+	watchers map[chan domain.UserEvent]struct{}
 }
 
 // NewUserService creates a new instance of the user service
 func NewUserService(repo domain.UserRepository) domain.UserService {
 	return &userService{
-		repo: repo,
+		repo:     repo,
+		watchers: make(map[chan domain.UserEvent]struct{}),
 	}
 }
 
@@ -33,10 +42,90 @@ func (s *userService) CreateUser(ctx context.Context, name, email string) (*doma
 		return nil, err
 	}
 
+	s.publish(domain.UserCreated, user)
 	return user, nil
 }
 
 // GetUser implements the domain.UserService interface
 func (s *userService) GetUser(ctx context.Context, id string) (*domain.User, error) {
 	return s.repo.GetByID(ctx, id)
-} 
\ No newline at end of file
+}
+
+// ListUsers implements the domain.UserService interface
+func (s *userService) ListUsers(ctx context.Context, filter domain.UserFilter) ([]*domain.User, error) {
+	return s.repo.List(ctx, filter)
+}
+
+// UpdateUser implements the domain.UserService interface. Empty fields are
+// left unchanged.
+func (s *userService) UpdateUser(ctx context.Context, id, name, email string) (*domain.User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	if name != "" {
+		user.Name = name
+	}
+	if email != "" {
+		user.Email = email
+	}
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.publish(domain.UserUpdated, user)
+	return user, nil
+}
+
+// DeleteUser implements the domain.UserService interface
+func (s *userService) DeleteUser(ctx context.Context, id string) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.publish(domain.UserDeleted, &domain.User{ID: id})
+	return nil
+}
+
+// WatchUsers implements the domain.UserService interface
+func (s *userService) WatchUsers(ctx context.Context) <-chan domain.UserEvent {
+	ch := make(chan domain.UserEvent, watchBuffer)
+
+	s.mu.Lock()
+	s.watchers[ch] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.watchers[ch]; ok {
+			delete(s.watchers, ch)
+			close(ch)
+		}
+	}()
+
+	return ch
+}
+
+// publish sends an event to every watcher, disconnecting watchers whose
+// buffer is full rather than blocking writers
+func (s *userService) publish(eventType domain.EventType, user *domain.User) {
+	copied := *user
+	event := domain.UserEvent{Type: eventType, User: &copied, Time: time.Now()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.watchers {
+		select {
+		case ch <- event:
+		default:
+			delete(s.watchers, ch)
+			close(ch)
+		}
+	}
+}
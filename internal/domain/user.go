@@ -2,9 +2,17 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	// ErrUserNotFound is returned when no user has the requested ID
+	ErrUserNotFound = errors.New("user not found")
+	// ErrEmailTaken is returned when another user has the email address
+	ErrEmailTaken = errors.New("email address already in use")
+)
+
 // User represents a user entity
 type User struct {
 	ID        string    `json:"id"`
@@ -14,10 +22,38 @@ type User struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// UserFilter selects a page of users ordered by creation time
+type UserFilter struct {
+	// Query matches users whose name or email contains it, ignoring case;
+	// empty matches every user
+	Query  string
+	Offset int
+	Limit  int
+}
+
+// EventType is the kind of change a UserEvent describes
+type EventType int
+
+// User event types
+const (
+	UserCreated EventType = iota + 1
+	UserUpdated
+	UserDeleted
+)
+
+// UserEvent describes a change to a user. Only the ID is set on the user
+// of a deletion.
+type UserEvent struct {
+	Type EventType
+	User *User
+	Time time.Time
+}
+
 // UserRepository defines the interface for user data storage
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id string) (*User, error)
+	List(ctx context.Context, filter UserFilter) ([]*User, error)
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id string) error
 }
@@ -26,4 +62,10 @@ type UserRepository interface {
 type UserService interface {
 	CreateUser(ctx context.Context, name, email string) (*User, error)
 	GetUser(ctx context.Context, id string) (*User, error)
+	ListUsers(ctx context.Context, filter UserFilter) ([]*User, error)
+	UpdateUser(ctx context.Context, id, name, email string) (*User, error)
+	DeleteUser(ctx context.Context, id string) error
+	// WatchUsers streams changes until ctx is done. The channel is also
+	// closed if the receiver falls too far behind.
+	WatchUsers(ctx context.Context) <-chan UserEvent
 } 
\ No newline at end of file
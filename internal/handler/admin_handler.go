@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	adminpb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/admin"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
+)
+
+// AdminHandler implements the AdminService gRPC service
+type AdminHandler struct {
+	adminpb.UnimplementedAdminServiceServer
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{}
+}
+
+// GetLogLevel handles the GetLogLevel RPC call
+func (h *AdminHandler) GetLogLevel(ctx context.Context, req *adminpb.GetLogLevelRequest) (*adminpb.LogLevelResponse, error) {
+	return logLevelResponse(), nil
+}
+
+// SetLogLevel handles the SetLogLevel RPC call
+func (h *AdminHandler) SetLogLevel(ctx context.Context, req *adminpb.SetLogLevelRequest) (*adminpb.LogLevelResponse, error) {
+	level, err := zapcore.ParseLevel(req.Level)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.Ttl != nil {
+		if err := req.Ttl.CheckValid(); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if req.Ttl.AsDuration() <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "ttl must be positive; omit it to change the level until reset")
+		}
+	}
+
+	logger.SetLevel(req.Logger, level, req.Ttl.AsDuration())
+	return logLevelResponse(), nil
+}
+
+// ResetLogLevel handles the ResetLogLevel RPC call
+func (h *AdminHandler) ResetLogLevel(ctx context.Context, req *adminpb.ResetLogLevelRequest) (*adminpb.LogLevelResponse, error) {
+	logger.ResetLevel(req.Logger)
+	return logLevelResponse(), nil
+}
+
+func logLevelResponse() *adminpb.LogLevelResponse {
+	resp := &adminpb.LogLevelResponse{}
+	for _, l := range logger.Levels() {
+		level := &adminpb.LoggerLevel{
+			Logger: l.Logger,
+			Level:  l.Level,
+		}
+		if l.ExpiresAt != nil {
+			level.ExpiresAt = timestamppb.New(*l.ExpiresAt)
+		}
+		resp.Levels = append(resp.Levels, level)
+	}
+	return resp
+}
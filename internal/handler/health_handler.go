@@ -16,13 +16,20 @@ import (
 type HealthHandler struct {
 	grpc_health_v1.UnimplementedHealthServer
 	statusMap map[string]grpc_health_v1.HealthCheckResponse_ServingStatus
-	mu        sync.RWMutex
+	// updates holds one channel per Watch stream, keyed by service name
+	updates  map[string]map[grpc_health_v1.Health_WatchServer]chan grpc_health_v1.HealthCheckResponse_ServingStatus
+	shutdown bool
+	mu       sync.RWMutex
 }
 
-// NewHealthHandler creates a new health check handler
+// NewHealthHandler creates a new health check handler. The overall server
+// status (empty service name) starts as SERVING.
 func NewHealthHandler() *HealthHandler {
 	return &HealthHandler{
-		statusMap: make(map[string]grpc_health_v1.HealthCheckResponse_ServingStatus),
+		statusMap: map[string]grpc_health_v1.HealthCheckResponse_ServingStatus{
+			"": grpc_health_v1.HealthCheckResponse_SERVING,
+		},
+		updates: make(map[string]map[grpc_health_v1.Health_WatchServer]chan grpc_health_v1.HealthCheckResponse_ServingStatus),
 	}
 }
 
@@ -32,14 +39,7 @@ func (h *HealthHandler) Check(ctx context.Context, req *grpc_health_v1.HealthChe
 	defer h.mu.RUnlock()
 
 	service := req.GetService()
-	logger.Debug("Health check received", zap.String("service", service))
-
-	// If no service is specified, return the overall server status
-	if service == "" {
-		return &grpc_health_v1.HealthCheckResponse{
-			Status: grpc_health_v1.HealthCheckResponse_SERVING,
-		}, nil
-	}
+	logger.FromContext(ctx).Named("handler.health").Debug("Health check received", zap.String("service", service))
 
 	svcStatus, ok := h.statusMap[service]
 	if !ok {
@@ -51,40 +51,109 @@ func (h *HealthHandler) Check(ctx context.Context, req *grpc_health_v1.HealthChe
 	}, nil
 }
 
-// Watch returns a stream of health check statuses
+// Watch streams the health status of a service. The current status is sent
+// immediately (SERVICE_UNKNOWN for services that are not registered yet),
+// followed by every subsequent change until the client cancels the stream.
 func (h *HealthHandler) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
 	service := req.GetService()
-	logger.Debug("Health watch received", zap.String("service", service))
+	log := logger.FromContext(stream.Context()).Named("handler.health")
+	log.Debug("Health watch received", zap.String("service", service))
 
-	// This is a simplified implementation that just returns the current status
-	// A complete implementation would watch for status changes
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	// Buffered so SetServingStatus never blocks on a slow watcher
+	update := make(chan grpc_health_v1.HealthCheckResponse_ServingStatus, 1)
 
-	// If no service is specified, return the overall server status
-	if service == "" {
-		return stream.Send(&grpc_health_v1.HealthCheckResponse{
-			Status: grpc_health_v1.HealthCheckResponse_SERVING,
-		})
+	h.mu.Lock()
+	if svcStatus, ok := h.statusMap[service]; ok {
+		update <- svcStatus
+	} else {
+		update <- grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+	if _, ok := h.updates[service]; !ok {
+		h.updates[service] = make(map[grpc_health_v1.Health_WatchServer]chan grpc_health_v1.HealthCheckResponse_ServingStatus)
+	}
+	h.updates[service][stream] = update
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.updates[service], stream)
+		if len(h.updates[service]) == 0 {
+			delete(h.updates, service)
+		}
+		h.mu.Unlock()
+		log.Debug("Health watch ended", zap.String("service", service))
+	}()
+
+	var lastSent grpc_health_v1.HealthCheckResponse_ServingStatus = -1
+	for {
+		select {
+		case svcStatus := <-update:
+			if svcStatus == lastSent {
+				continue
+			}
+			lastSent = svcStatus
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: svcStatus}); err != nil {
+				return status.Error(codes.Canceled, "stream has ended")
+			}
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		}
 	}
+}
 
-	svcStatus, ok := h.statusMap[service]
-	if !ok {
-		svcStatus = grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+// SetServingStatus updates the serving status of a service and notifies its
+// watchers. Updates are ignored after Shutdown until Resume is called.
+func (h *HealthHandler) SetServingStatus(service string, status grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.shutdown {
+		logger.Info("Ignoring health status update during shutdown",
+			zap.String("service", service),
+			zap.String("status", status.String()),
+		)
+		return
 	}
+	h.setServingStatusLocked(service, status)
+}
 
-	return stream.Send(&grpc_health_v1.HealthCheckResponse{
-		Status: svcStatus,
-	})
+// Shutdown marks every service as NOT_SERVING and ignores further status
+// updates until Resume is called
+func (h *HealthHandler) Shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.shutdown = true
+	for service := range h.statusMap {
+		h.setServingStatusLocked(service, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	}
 }
 
-// SetServingStatus updates the serving status of a service
-func (h *HealthHandler) SetServingStatus(service string, status grpc_health_v1.HealthCheckResponse_ServingStatus) {
+// Resume marks every service as SERVING and accepts status updates again
+func (h *HealthHandler) Resume() {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+
+	h.shutdown = false
+	for service := range h.statusMap {
+		h.setServingStatusLocked(service, grpc_health_v1.HealthCheckResponse_SERVING)
+	}
+}
+
+// setServingStatusLocked records the status and pushes it to watchers. The
+// caller must hold h.mu for writing.
+func (h *HealthHandler) setServingStatusLocked(service string, status grpc_health_v1.HealthCheckResponse_ServingStatus) {
 	h.statusMap[service] = status
+	for _, update := range h.updates[service] {
+		// Drop an unsent previous update so the watcher sees the latest status
+		select {
+		case <-update:
+		default:
+		}
+		update <- status
+	}
 	logger.Info("Health status updated",
 		zap.String("service", service),
 		zap.String("status", status.String()),
 	)
-} 
\ No newline at end of file
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Page sizes of list calls
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
+
+// pageTokenPrefix versions page tokens so their format can change
+const pageTokenPrefix = "v1:"
+
+// pageLimit returns the number of results to return for a requested page size
+func pageLimit(pageSize int32) (int, error) {
+	switch {
+	case pageSize < 0:
+		return 0, errors.New("page_size must not be negative")
+	case pageSize == 0:
+		return defaultPageSize, nil
+	case pageSize > maxPageSize:
+		return maxPageSize, nil
+	default:
+		return int(pageSize), nil
+	}
+}
+
+// encodePageToken returns an opaque token for the page starting at offset
+func encodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(pageTokenPrefix + strconv.Itoa(offset)))
+}
+
+// decodePageToken returns the offset of the page a token refers to; an empty
+// token is the first page
+func decodePageToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, errors.New("page_token is invalid")
+	}
+	rest, ok := strings.CutPrefix(string(b), pageTokenPrefix)
+	if !ok {
+		return 0, errors.New("page_token is invalid")
+	}
+	offset, err := strconv.Atoi(rest)
+	if err != nil || offset < 0 {
+		return 0, errors.New("page_token is invalid")
+	}
+	return offset, nil
+}
@@ -2,14 +2,20 @@ package handler
 
 import (
 	"context"
+	"errors"
 
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/internal/app"
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/internal/domain"
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/internal/repo/sqlite"
-	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/user"
+	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/user"
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/db"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // UserHandler implements the UserService gRPC service
@@ -38,6 +44,16 @@ func NewUserHandler() *UserHandler {
 	}
 }
 
+// Ping verifies the storage connection is alive
+func (h *UserHandler) Ping(ctx context.Context) error {
+	return h.repo.Ping(ctx)
+}
+
+// ProbeWrite verifies the storage accepts writes
+func (h *UserHandler) ProbeWrite(ctx context.Context) error {
+	return h.repo.ProbeWrite(ctx)
+}
+
 // Close closes the repository connection
 func (h *UserHandler) Close() error {
 	return h.repo.Close()
@@ -45,43 +61,209 @@ func (h *UserHandler) Close() error {
 
 // CreateUser handles the CreateUser RPC call
 func (h *UserHandler) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.UserResponse, error) {
+	var violations []*errdetails.BadRequest_FieldViolation
 	if req.Name == "" {
-		return nil, status.Error(codes.InvalidArgument, "name is required")
+		violations = append(violations, fieldViolation("name", "name is required"))
 	}
 	if req.Email == "" {
-		return nil, status.Error(codes.InvalidArgument, "email is required")
+		violations = append(violations, fieldViolation("email", "email is required"))
+	}
+	if len(violations) > 0 {
+		return nil, invalidArgument(violations...)
 	}
 
+	log := logger.FromContext(ctx).Named("handler.user")
+
 	user, err := h.service.CreateUser(ctx, req.Name, req.Email)
+	if errors.Is(err, domain.ErrEmailTaken) {
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
 	if err != nil {
+		log.Error("Failed to create user", zap.Error(err))
 		return nil, status.Error(codes.Internal, err.Error())
 	}
+	log.Debug("User created", zap.String("user_id", user.ID))
 
-	return &pb.UserResponse{
-		Id:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-	}, nil
+	return toProto(user), nil
 }
 
 // GetUser handles the GetUser RPC call
 func (h *UserHandler) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.UserResponse, error) {
 	if req.Id == "" {
-		return nil, status.Error(codes.InvalidArgument, "id is required")
+		return nil, invalidArgument(fieldViolation("id", "id is required"))
 	}
 
+	log := logger.FromContext(ctx).Named("handler.user")
+
 	user, err := h.service.GetUser(ctx, req.Id)
 	if err != nil {
+		log.Error("Failed to get user", zap.String("user_id", req.Id), zap.Error(err))
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
 	if user == nil {
+		log.Debug("User not found", zap.String("user_id", req.Id))
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	return toProto(user), nil
+}
+
+// ListUsers handles the ListUsers RPC call
+func (h *UserHandler) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	users, next, err := h.listPage(ctx, "", req.PageSize, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListUsersResponse{Users: users, NextPageToken: next}, nil
+}
+
+// SearchUsers handles the SearchUsers RPC call
+func (h *UserHandler) SearchUsers(ctx context.Context, req *pb.SearchUsersRequest) (*pb.SearchUsersResponse, error) {
+	if req.Query == "" {
+		return nil, invalidArgument(fieldViolation("query", "query is required"))
+	}
+
+	users, next, err := h.listPage(ctx, req.Query, req.PageSize, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SearchUsersResponse{Users: users, NextPageToken: next}, nil
+}
+
+// listPage fetches the page of users selected by the request's paging
+// fields, plus one to learn whether another page follows
+func (h *UserHandler) listPage(ctx context.Context, query string, pageSize int32, pageToken string) ([]*pb.UserResponse, string, error) {
+	var violations []*errdetails.BadRequest_FieldViolation
+	limit, err := pageLimit(pageSize)
+	if err != nil {
+		violations = append(violations, fieldViolation("page_size", err.Error()))
+	}
+	offset, err := decodePageToken(pageToken)
+	if err != nil {
+		violations = append(violations, fieldViolation("page_token", err.Error()))
+	}
+	if len(violations) > 0 {
+		return nil, "", invalidArgument(violations...)
+	}
+
+	users, err := h.service.ListUsers(ctx, domain.UserFilter{Query: query, Offset: offset, Limit: limit + 1})
+	if err != nil {
+		logger.FromContext(ctx).Named("handler.user").Error("Failed to list users", zap.Error(err))
+		return nil, "", status.Error(codes.Internal, err.Error())
+	}
+
+	var next string
+	if len(users) > limit {
+		users = users[:limit]
+		next = encodePageToken(offset + limit)
+	}
+
+	resp := make([]*pb.UserResponse, len(users))
+	for i, user := range users {
+		resp[i] = toProto(user)
+	}
+	return resp, next, nil
+}
+
+// UpdateUser handles the UpdateUser RPC call
+func (h *UserHandler) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UserResponse, error) {
+	var violations []*errdetails.BadRequest_FieldViolation
+	if req.Id == "" {
+		violations = append(violations, fieldViolation("id", "id is required"))
+	}
+	if req.Name == "" && req.Email == "" {
+		violations = append(violations, fieldViolation("name", "name or email is required"))
+	}
+	if len(violations) > 0 {
+		return nil, invalidArgument(violations...)
+	}
+
+	log := logger.FromContext(ctx).Named("handler.user")
+
+	user, err := h.service.UpdateUser(ctx, req.Id, req.Name, req.Email)
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound):
 		return nil, status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, domain.ErrEmailTaken):
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	case err != nil:
+		log.Error("Failed to update user", zap.String("user_id", req.Id), zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
 	}
+	log.Debug("User updated", zap.String("user_id", user.ID))
+
+	return toProto(user), nil
+}
+
+// DeleteUser handles the DeleteUser RPC call
+func (h *UserHandler) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*emptypb.Empty, error) {
+	if req.Id == "" {
+		return nil, invalidArgument(fieldViolation("id", "id is required"))
+	}
+
+	log := logger.FromContext(ctx).Named("handler.user")
 
-	return &pb.UserResponse{
+	err := h.service.DeleteUser(ctx, req.Id)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if err != nil {
+		log.Error("Failed to delete user", zap.String("user_id", req.Id), zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	log.Debug("User deleted", zap.String("user_id", req.Id))
+
+	return &emptypb.Empty{}, nil
+}
+
+// WatchUsers handles the WatchUsers RPC call, streaming changes until the
+// client goes away
+func (h *UserHandler) WatchUsers(req *pb.WatchUsersRequest, stream pb.UserService_WatchUsersServer) error {
+	ctx := stream.Context()
+	events := h.service.WatchUsers(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return status.Error(codes.ResourceExhausted, "watcher fell behind; restart the watch")
+			}
+			if err := stream.Send(&pb.UserEvent{
+				Type: eventTypes[event.Type],
+				User: toProto(event.User),
+				Time: timestamppb.New(event.Time),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// eventTypes maps domain event types to their protobuf values
+var eventTypes = map[domain.EventType]pb.UserEvent_Type{
+	domain.UserCreated: pb.UserEvent_CREATED,
+	domain.UserUpdated: pb.UserEvent_UPDATED,
+	domain.UserDeleted: pb.UserEvent_DELETED,
+}
+
+// toProto converts a domain user to its protobuf representation
+func toProto(user *domain.User) *pb.UserResponse {
+	resp := &pb.UserResponse{
 		Id:    user.ID,
 		Name:  user.Name,
 		Email: user.Email,
-	}, nil
+	}
+	if !user.CreatedAt.IsZero() {
+		resp.CreatedAt = timestamppb.New(user.CreatedAt)
+	}
+	if !user.UpdatedAt.IsZero() {
+		resp.UpdatedAt = timestamppb.New(user.UpdatedAt)
+	}
+	return resp
 } 
\ No newline at end of file
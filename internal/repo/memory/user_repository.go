@@ -3,11 +3,18 @@ package memory
 import (
 	"context"
 	"errors"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/internal/domain"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
+	"go.uber.org/zap"
 )
 
+// loggerName is the name of the repository logger, usable for level overrides
+const loggerName = "repo.memory"
+
 // InMemoryUserRepository is an in-memory implementation of the UserRepository interface
 type InMemoryUserRepository struct {
 	users map[string]*domain.User
@@ -23,6 +30,8 @@ func NewInMemoryUserRepository() *InMemoryUserRepository {
 
 // Create adds a new user to the in-memory store
 func (r *InMemoryUserRepository) Create(ctx context.Context, user *domain.User) error {
+	logger.FromContext(ctx).Named(loggerName).Debug("Storing user", zap.String("user_id", user.ID))
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -36,6 +45,8 @@ func (r *InMemoryUserRepository) Create(ctx context.Context, user *domain.User)
 
 // GetByID retrieves a user by ID from the in-memory store
 func (r *InMemoryUserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	logger.FromContext(ctx).Named(loggerName).Debug("Looking up user", zap.String("user_id", id))
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -47,13 +58,47 @@ func (r *InMemoryUserRepository) GetByID(ctx context.Context, id string) (*domai
 	return user, nil
 }
 
+// List retrieves a page of users matching the filter from the in-memory
+// store, oldest first
+func (r *InMemoryUserRepository) List(ctx context.Context, filter domain.UserFilter) ([]*domain.User, error) {
+	logger.FromContext(ctx).Named(loggerName).Debug("Listing users", zap.String("query", filter.Query))
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	query := strings.ToLower(filter.Query)
+	var users []*domain.User
+	for _, user := range r.users {
+		if strings.Contains(strings.ToLower(user.Name), query) || strings.Contains(strings.ToLower(user.Email), query) {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.Before(users[j].CreatedAt)
+		}
+		return users[i].ID < users[j].ID
+	})
+
+	if filter.Offset >= len(users) {
+		return nil, nil
+	}
+	users = users[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(users) {
+		users = users[:filter.Limit]
+	}
+	return users, nil
+}
+
 // Update modifies an existing user in the in-memory store
 func (r *InMemoryUserRepository) Update(ctx context.Context, user *domain.User) error {
+	logger.FromContext(ctx).Named(loggerName).Debug("Updating user", zap.String("user_id", user.ID))
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if _, exists := r.users[user.ID]; !exists {
-		return errors.New("user not found")
+		return domain.ErrUserNotFound
 	}
 
 	r.users[user.ID] = user
@@ -62,11 +107,13 @@ func (r *InMemoryUserRepository) Update(ctx context.Context, user *domain.User)
 
 // Delete removes a user from the in-memory store
 func (r *InMemoryUserRepository) Delete(ctx context.Context, id string) error {
+	logger.FromContext(ctx).Named(loggerName).Debug("Deleting user", zap.String("user_id", id))
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if _, exists := r.users[id]; !exists {
-		return errors.New("user not found")
+		return domain.ErrUserNotFound
 	}
 
 	delete(r.users, id)
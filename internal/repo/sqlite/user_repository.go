@@ -3,12 +3,19 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/internal/domain"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
+	"github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
 )
 
+// loggerName is the name of the repository logger, usable for level overrides
+const loggerName = "repo.sqlite"
+
 // SQLiteUserRepository is a SQLite implementation of the UserRepository interface
 type SQLiteUserRepository struct {
 	db *sql.DB
@@ -40,11 +47,38 @@ func NewSQLiteUserRepository(dbPath string) (*SQLiteUserRepository, error) {
 		return nil, err
 	}
 
+	// Create the table used by the write health probe
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS health_probe (
+			id INTEGER PRIMARY KEY,
+			checked_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return nil, err
+	}
+
 	return &SQLiteUserRepository{
 		db: db,
 	}, nil
 }
 
+// Ping verifies the database connection is alive
+func (r *SQLiteUserRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// ProbeWrite verifies the database accepts writes by upserting a row in a
+// dedicated probe table
+func (r *SQLiteUserRepository) ProbeWrite(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO health_probe (id, checked_at)
+		VALUES (1, ?)
+		ON CONFLICT(id) DO UPDATE SET checked_at = excluded.checked_at
+	`, time.Now())
+	return err
+}
+
 // Close closes the database connection
 func (r *SQLiteUserRepository) Close() error {
 	return r.db.Close()
@@ -52,56 +86,154 @@ func (r *SQLiteUserRepository) Close() error {
 
 // Create adds a new user to the SQLite database
 func (r *SQLiteUserRepository) Create(ctx context.Context, user *domain.User) error {
+	log := logger.FromContext(ctx).Named(loggerName)
+	log.Debug("Inserting user", zap.String("user_id", user.ID))
+
 	_, err := r.db.ExecContext(ctx, `
 		INSERT INTO users (id, name, email, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?)
 	`, user.ID, user.Name, user.Email, user.CreatedAt, user.UpdatedAt)
+	if isUniqueViolation(err) {
+		return domain.ErrEmailTaken
+	}
+	if err != nil {
+		log.Error("Failed to insert user", zap.String("user_id", user.ID), zap.Error(err))
+	}
 	return err
 }
 
 // GetByID retrieves a user by ID from the SQLite database
 func (r *SQLiteUserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	log := logger.FromContext(ctx).Named(loggerName)
+	log.Debug("Selecting user", zap.String("user_id", id))
+
 	row := r.db.QueryRowContext(ctx, `
 		SELECT id, name, email, created_at, updated_at
 		FROM users
 		WHERE id = ?
 	`, id)
 
-	var user domain.User
-	var createdAt, updatedAt string
-
-	err := row.Scan(&user.ID, &user.Name, &user.Email, &createdAt, &updatedAt)
+	user, err := scanUser(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
+		log.Error("Failed to select user", zap.String("user_id", id), zap.Error(err))
 		return nil, err
 	}
 
-	// Parse the time strings
-	user.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-	user.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return user, nil
+}
 
-	return &user, nil
+// List retrieves a page of users matching the filter, oldest first
+func (r *SQLiteUserRepository) List(ctx context.Context, filter domain.UserFilter) ([]*domain.User, error) {
+	log := logger.FromContext(ctx).Named(loggerName)
+	log.Debug("Listing users",
+		zap.String("query", filter.Query),
+		zap.Int("offset", filter.Offset),
+		zap.Int("limit", filter.Limit),
+	)
+
+	// LIKE is case-insensitive for ASCII in SQLite; wildcards in the query
+	// are matched literally
+	pattern := "%" + likeEscaper.Replace(filter.Query) + "%"
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, email, created_at, updated_at
+		FROM users
+		WHERE name LIKE ? ESCAPE '\' OR email LIKE ? ESCAPE '\'
+		ORDER BY created_at, id
+		LIMIT ? OFFSET ?
+	`, pattern, pattern, filter.Limit, filter.Offset)
+	if err != nil {
+		log.Error("Failed to list users", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			log.Error("Failed to scan user", zap.Error(err))
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
 }
 
 // Update modifies an existing user in the SQLite database
 func (r *SQLiteUserRepository) Update(ctx context.Context, user *domain.User) error {
+	log := logger.FromContext(ctx).Named(loggerName)
+	log.Debug("Updating user", zap.String("user_id", user.ID))
+
 	user.UpdatedAt = time.Now()
 
-	_, err := r.db.ExecContext(ctx, `
+	result, err := r.db.ExecContext(ctx, `
 		UPDATE users
 		SET name = ?, email = ?, updated_at = ?
 		WHERE id = ?
 	`, user.Name, user.Email, user.UpdatedAt, user.ID)
-	return err
+	if isUniqueViolation(err) {
+		return domain.ErrEmailTaken
+	}
+	if err != nil {
+		log.Error("Failed to update user", zap.String("user_id", user.ID), zap.Error(err))
+		return err
+	}
+	return requireRow(result)
 }
 
 // Delete removes a user from the SQLite database
 func (r *SQLiteUserRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.db.ExecContext(ctx, `
+	log := logger.FromContext(ctx).Named(loggerName)
+	log.Debug("Deleting user", zap.String("user_id", id))
+
+	result, err := r.db.ExecContext(ctx, `
 		DELETE FROM users
 		WHERE id = ?
 	`, id)
-	return err
+	if err != nil {
+		log.Error("Failed to delete user", zap.String("user_id", id), zap.Error(err))
+		return err
+	}
+	return requireRow(result)
+}
+
+// likeEscaper escapes LIKE wildcards
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// scanUser reads a user from a row selected as id, name, email,
+// created_at, updated_at
+func scanUser(row interface{ Scan(dest ...any) error }) (*domain.User, error) {
+	var user domain.User
+	var createdAt, updatedAt string
+
+	if err := row.Scan(&user.ID, &user.Name, &user.Email, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	// Parse the time strings
+	user.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	user.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+
+	return &user, nil
+}
+
+// requireRow returns domain.ErrUserNotFound if the statement matched no row
+func requireRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is a UNIQUE constraint failure
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
 } 
\ No newline at end of file
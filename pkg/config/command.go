@@ -0,0 +1,57 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+const commandUsage = `Usage: %s config <command>
+
+Commands:
+  validate   check the configuration and report every problem
+  print      show the effective configuration with the source of each value
+`
+
+// RunCommand runs the config subcommand of a binary, given the arguments
+// after "config", and returns its exit code: 0 when the configuration is
+// valid, 1 when it is not and 2 on usage errors
+func RunCommand(program string, args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 || (args[0] != "validate" && args[0] != "print") {
+		fmt.Fprintf(stderr, commandUsage, program)
+		return 2
+	}
+
+	l, err := load(nil)
+	var invalid *ValidationError
+	if err != nil && !errors.As(err, &invalid) {
+		fmt.Fprintf(stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+	v := l.v
+
+	file := v.ConfigFileUsed()
+	if file == "" {
+		file = "none, defaults and environment only"
+	}
+
+	if args[0] == "print" {
+		fmt.Fprintf(stdout, "Config file: %s\n\n", file)
+		w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tSOURCE\tVALUE")
+		for _, s := range settings(v, l.cfg) {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.key, s.source, s.value)
+		}
+		w.Flush()
+	}
+
+	if invalid != nil {
+		fmt.Fprintln(stderr, invalid)
+		return 1
+	}
+	if args[0] == "validate" {
+		fmt.Fprintf(stdout, "Configuration is valid (config file: %s)\n", file)
+	}
+	return 0
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
@@ -14,6 +15,10 @@ type Config struct {
 	App      AppConfig      `mapstructure:"app"`
 	Server   ServerConfig   `mapstructure:"server"`
 	Database DatabaseConfig `mapstructure:"database"`
+	Logging  LoggingConfig  `mapstructure:"logging"`
+	Health   HealthConfig   `mapstructure:"health"`
+	Shutdown ShutdownConfig `mapstructure:"shutdown"`
+	Gateway  GatewayConfig  `mapstructure:"gateway"`
 }
 
 // AppConfig holds general application configuration
@@ -23,13 +28,100 @@ type AppConfig struct {
 	LogLevel    string `mapstructure:"log_level"`
 }
 
-// ServerConfig holds server configuration
+// ServerConfig holds server configuration. Durations are in seconds.
 type ServerConfig struct {
-	Port         int    `mapstructure:"port"`
-	ReadTimeout  int    `mapstructure:"read_timeout"`
-	WriteTimeout int    `mapstructure:"write_timeout"`
-	IdleTimeout  int    `mapstructure:"idle_timeout"`
-	Host         string `mapstructure:"host"`
+	Port int `mapstructure:"port"`
+	// ReadTimeout bounds establishing a connection, including the HTTP/2
+	// handshake
+	ReadTimeout int `mapstructure:"read_timeout"`
+	// WriteTimeout is the deadline applied to unary calls that arrive
+	// without one; 0 disables
+	WriteTimeout int `mapstructure:"write_timeout"`
+	// Timeouts overrides WriteTimeout per RPC, keyed by method name such as
+	// ListUsers. Streaming RPCs only get a deadline when listed here.
+	Timeouts map[string]int `mapstructure:"timeouts"`
+	// IdleTimeout closes connections that have had no RPCs for this long
+	IdleTimeout int `mapstructure:"idle_timeout"`
+	// Host is the address to listen on; empty or 0.0.0.0 listens on all
+	// interfaces
+	Host string `mapstructure:"host"`
+	// Listen lists addresses to listen on instead of Host and Port:
+	// tcp://host:port or unix:///path/to.sock with an optional ?mode=0660
+	Listen []string `mapstructure:"listen"`
+	// SinglePort serves gRPC, gRPC-Web and the REST gateway on one listener
+	SinglePort bool `mapstructure:"single_port"`
+
+	// MaxConcurrentStreams limits the concurrent RPCs of each connection;
+	// 0 is unlimited
+	MaxConcurrentStreams int `mapstructure:"max_concurrent_streams"`
+	// MaxRecvMsgSizeBytes and MaxSendMsgSizeBytes limit message sizes; 0
+	// keeps gRPC's defaults of 4 MiB received and unlimited sent
+	MaxRecvMsgSizeBytes int             `mapstructure:"max_recv_msg_size_bytes"`
+	MaxSendMsgSizeBytes int             `mapstructure:"max_send_msg_size_bytes"`
+	Keepalive           KeepaliveConfig `mapstructure:"keepalive"`
+
+	Auth      AuthConfig      `mapstructure:"auth"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	TLS       TLSConfig       `mapstructure:"tls"`
+}
+
+// KeepaliveConfig holds the gRPC server's connection keepalive settings, in
+// seconds. Zero keeps gRPC's default.
+type KeepaliveConfig struct {
+	// MaxConnectionAge closes connections after this long so clients
+	// rebalance, allowing MaxConnectionAgeGrace for calls in flight
+	MaxConnectionAge      int `mapstructure:"max_connection_age"`
+	MaxConnectionAgeGrace int `mapstructure:"max_connection_age_grace"`
+	// Time is how long a connection may be idle before the server pings
+	// the client, which then has Timeout to answer
+	Time    int `mapstructure:"time"`
+	Timeout int `mapstructure:"timeout"`
+	// MinTime is the shortest ping interval allowed from clients; clients
+	// pinging more often are disconnected
+	MinTime int `mapstructure:"min_time"`
+	// PermitWithoutStream allows client pings while no RPC is active
+	PermitWithoutStream bool `mapstructure:"permit_without_stream"`
+}
+
+// AuthConfig holds request authentication settings of the gRPC server
+type AuthConfig struct {
+	// Enabled requires an authorization header on every method except
+	// PublicMethods
+	Enabled bool `mapstructure:"enabled"`
+	// PublicMethods lists full method names that need no credentials,
+	// e.g. /grpc.health.v1.Health/Check
+	PublicMethods []string `mapstructure:"public_methods"`
+	// JWTSecret verifies HS256-signed bearer tokens. Without it any
+	// non-empty token is accepted. Give it as a file:// or env: reference
+	// or through APP_SERVER_AUTH_JWT_SECRET_FILE rather than in the file.
+	JWTSecret Secret `mapstructure:"jwt_secret"`
+	// APIKeys are accepted in the x-api-key header instead of a bearer
+	// token. Each may be a file:// or env: reference.
+	APIKeys []Secret `mapstructure:"api_keys"`
+}
+
+// RateLimitConfig holds the token bucket limits of the gRPC server. Each
+// method has its own bucket.
+type RateLimitConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// RequestsPerSecond is the sustained rate allowed for each method
+	RequestsPerSecond int `mapstructure:"requests_per_second"`
+	// Burst is the number of requests allowed at once above the rate
+	Burst int `mapstructure:"burst"`
+	// Methods overrides RequestsPerSecond per RPC, keyed by method name
+	// such as CreateUser
+	Methods map[string]int `mapstructure:"methods"`
+}
+
+// TLSConfig holds the server certificate. A changed certificate or key is
+// applied to new connections without a restart.
+type TLSConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Cert and Key are the PEM-encoded certificate chain and private key,
+	// normally file:// references such as file:///etc/tls/tls.crt so that
+	// renewed files are picked up
+	Cert Secret `mapstructure:"cert"`
+	Key  Secret `mapstructure:"key"`
 }
 
 // DatabaseConfig holds database configuration
@@ -37,8 +129,232 @@ type DatabaseConfig struct {
 	SQLiteDBPath string `mapstructure:"sqlite_db_path"`
 }
 
-// Load loads the configuration from files and environment variables
+// HealthConfig holds dependency health check configuration
+type HealthConfig struct {
+	// CheckInterval is the number of seconds between check runs
+	CheckInterval int `mapstructure:"check_interval"`
+	// CheckTimeout is the number of seconds a single check may take
+	CheckTimeout int `mapstructure:"check_timeout"`
+	// MinFreeDiskMB is the free space required on the database volume
+	MinFreeDiskMB int `mapstructure:"min_free_disk_mb"`
+}
+
+// LoggingConfig holds logger and request/response logging configuration
+type LoggingConfig struct {
+	// Format is the log encoding: json or console. Empty selects json in
+	// production and console otherwise.
+	Format string `mapstructure:"format"`
+	// Outputs lists log sinks: "stderr", "stdout" or a file path
+	Outputs  []string          `mapstructure:"outputs"`
+	Rotation LogRotationConfig `mapstructure:"rotation"`
+	Sampling LogSamplingConfig `mapstructure:"sampling"`
+
+	// RedactFields lists proto fields whose values are masked in logged payloads.
+	// Entries are either a bare field name ("email") or a fully-qualified
+	// field name ("user.CreateUserRequest.email").
+	RedactFields []string `mapstructure:"redact_fields"`
+	// RedactMode is either "mask" or "hash"
+	RedactMode string `mapstructure:"redact_mode"`
+	// BodyLevel is the default payload logging level: none, metadata or full
+	BodyLevel string `mapstructure:"body_level"`
+	// MethodBodyLevels overrides BodyLevel for individual gRPC methods
+	MethodBodyLevels []MethodBodyLevel `mapstructure:"method_body_levels"`
+	// MaxPayloadBytes truncates logged payloads larger than this size (0 disables)
+	MaxPayloadBytes int `mapstructure:"max_payload_bytes"`
+}
+
+// LogRotationConfig holds rotation settings for file log outputs
+type LogRotationConfig struct {
+	MaxSizeMB     int `mapstructure:"max_size_mb"`
+	IntervalHours int `mapstructure:"interval_hours"`
+	MaxBackups    int `mapstructure:"max_backups"`
+}
+
+// GatewayConfig holds HTTP settings of the REST gateway
+type GatewayConfig struct {
+	// Listen lists addresses to listen on, like server.listen. The -listen
+	// flag overrides it, and without either the gateway listens on
+	// -http-port.
+	Listen []string `mapstructure:"listen"`
+	// AccessLog enables per-request access logs
+	AccessLog bool `mapstructure:"access_log"`
+	// MetricsPath serves Prometheus metrics on the gateway port; empty disables
+	MetricsPath string `mapstructure:"metrics_path"`
+	// BasePath is the path prefix clients use to reach the API, reported in
+	// the OpenAPI documents when the gateway is behind a path-based proxy
+	BasePath string `mapstructure:"base_path"`
+	// TrustedProxies lists proxy addresses or CIDR ranges whose
+	// X-Forwarded-For header is trusted to identify the client
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+
+	Upstream        UpstreamConfig        `mapstructure:"upstream"`
+	Headers         HeadersConfig         `mapstructure:"headers"`
+	CORS            CORSConfig            `mapstructure:"cors"`
+	Compression     CompressionConfig     `mapstructure:"compression"`
+	SecurityHeaders SecurityHeadersConfig `mapstructure:"security_headers"`
+}
+
+// UpstreamConfig holds the gateway's connection to the gRPC server
+type UpstreamConfig struct {
+	// LoadBalancing is the gRPC load balancing policy, pick_first or
+	// round_robin. round_robin spreads calls over every address a dns:///
+	// endpoint resolves to.
+	LoadBalancing string `mapstructure:"load_balancing"`
+	// HealthCheck enables client-side health checking, so backends that do
+	// not report HealthCheckService as SERVING receive no calls
+	HealthCheck        bool   `mapstructure:"health_check"`
+	HealthCheckService string `mapstructure:"health_check_service"`
+	// Timeout is the deadline for upstream calls in seconds; 0 disables
+	Timeout int `mapstructure:"timeout"`
+	// Timeouts overrides Timeout per RPC, keyed by method name such as GetUser
+	Timeouts map[string]int `mapstructure:"timeouts"`
+
+	Retry          RetryConfig          `mapstructure:"retry"`
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+	TLS            UpstreamTLSConfig    `mapstructure:"tls"`
+}
+
+// UpstreamTLSConfig holds the gateway's TLS settings for connecting to a
+// server with server.tls enabled
+type UpstreamTLSConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// CAFile verifies the server certificate instead of the system roots
+	CAFile string `mapstructure:"ca_file"`
+	// ServerName overrides the name the certificate is verified against
+	ServerName string `mapstructure:"server_name"`
+}
+
+// RetryConfig holds the retry policy for idempotent upstream calls
+type RetryConfig struct {
+	// MaxAttempts includes the first attempt; 1 disables retries
+	MaxAttempts      int `mapstructure:"max_attempts"`
+	InitialBackoffMs int `mapstructure:"initial_backoff_ms"`
+	MaxBackoffMs     int `mapstructure:"max_backoff_ms"`
+	// Methods lists the RPCs that are safe to retry
+	Methods []string `mapstructure:"methods"`
+	// RetryableCodes are the gRPC status codes that trigger a retry, e.g. UNAVAILABLE
+	RetryableCodes []string `mapstructure:"retryable_codes"`
+}
+
+// CircuitBreakerConfig holds the settings of the breaker that fails upstream
+// calls fast while the server is down
+type CircuitBreakerConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// FailureThreshold is the number of consecutive failures that opens the circuit
+	FailureThreshold int `mapstructure:"failure_threshold"`
+	// OpenTimeout is how long the circuit stays open before a trial call,
+	// in seconds
+	OpenTimeout int `mapstructure:"open_timeout"`
+}
+
+// HeadersConfig selects the headers mapped between HTTP and gRPC metadata.
+// Names are case-insensitive and a trailing * matches a prefix. Headers not
+// listed keep grpc-gateway's default mapping.
+type HeadersConfig struct {
+	// Incoming request headers forwarded to the server as metadata of the
+	// same, lower-cased name. Authorization and X-Request-Id are always
+	// forwarded.
+	Incoming []string `mapstructure:"incoming"`
+	// Outgoing response metadata returned to clients as headers of the same
+	// name instead of with the Grpc-Metadata- prefix
+	Outgoing []string `mapstructure:"outgoing"`
+}
+
+// CORSConfig holds cross-origin resource sharing settings. CORS is disabled
+// while AllowedOrigins is empty.
+type CORSConfig struct {
+	// AllowedOrigins may contain "*" or wildcard subdomains such as
+	// "https://*.example.com"
+	AllowedOrigins   []string `mapstructure:"allowed_origins"`
+	AllowedMethods   []string `mapstructure:"allowed_methods"`
+	AllowedHeaders   []string `mapstructure:"allowed_headers"`
+	ExposedHeaders   []string `mapstructure:"exposed_headers"`
+	AllowCredentials bool     `mapstructure:"allow_credentials"`
+	// MaxAge is how long browsers may cache preflight results, in seconds
+	MaxAge int `mapstructure:"max_age"`
+}
+
+// CompressionConfig holds response compression settings
+type CompressionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MinSizeBytes is the smallest response body worth compressing
+	MinSizeBytes int `mapstructure:"min_size_bytes"`
+	// Encodings in order of preference: br, gzip
+	Encodings    []string `mapstructure:"encodings"`
+	ContentTypes []string `mapstructure:"content_types"`
+}
+
+// SecurityHeadersConfig holds the security headers added to responses
+type SecurityHeadersConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// HSTSMaxAge is sent on HTTPS requests only, in seconds; 0 disables HSTS
+	HSTSMaxAge            int    `mapstructure:"hsts_max_age"`
+	HSTSIncludeSubdomains bool   `mapstructure:"hsts_include_subdomains"`
+	ContentSecurityPolicy string `mapstructure:"content_security_policy"`
+	// SwaggerContentSecurityPolicy applies to the Swagger UI, which needs
+	// to load its own scripts, styles and images
+	SwaggerContentSecurityPolicy string `mapstructure:"swagger_content_security_policy"`
+	FrameOptions                 string `mapstructure:"frame_options"`
+	ReferrerPolicy               string `mapstructure:"referrer_policy"`
+}
+
+// ShutdownConfig holds graceful shutdown timing, in seconds
+type ShutdownConfig struct {
+	// PreStopDelay is how long to keep serving after reporting NOT_SERVING,
+	// giving load balancers time to stop routing new requests
+	PreStopDelay int `mapstructure:"pre_stop_delay"`
+	// DrainTimeout bounds the wait for in-flight requests before they are
+	// cancelled
+	DrainTimeout int `mapstructure:"drain_timeout"`
+}
+
+// LogSamplingConfig holds zap sampling settings. Within each second the first
+// Initial entries with the same level and message are logged, then every
+// Thereafter-th entry.
+type LogSamplingConfig struct {
+	Enabled    bool `mapstructure:"enabled"`
+	Initial    int  `mapstructure:"initial"`
+	Thereafter int  `mapstructure:"thereafter"`
+}
+
+// MethodBodyLevel sets the payload logging level for a single gRPC method
+type MethodBodyLevel struct {
+	Method string `mapstructure:"method"`
+	Level  string `mapstructure:"level"`
+}
+
+// Load loads the configuration from files and environment variables and
+// validates it. Validation failures are returned as a *ValidationError.
 func Load(configPaths ...string) (*Config, error) {
+	l, err := load(configPaths)
+	if err != nil {
+		return nil, err
+	}
+	cfg := l.cfg
+
+	// Ensure database path exists
+	if cfg.Database.SQLiteDBPath != "" {
+		dir := filepath.Dir(cfg.Database.SQLiteDBPath)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("unable to create database directory: %s", err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// loaded is a configuration along with where its values came from
+type loaded struct {
+	cfg *Config
+	v   *viper.Viper
+	// files are the secret files read besides the config file
+	files []string
+}
+
+// load reads the configuration and validates it. When only validation fails
+// the result is returned with the *ValidationError, so the effective values
+// can still be shown.
+func load(configPaths []string) (*loaded, error) {
 	v := viper.New()
 	v.SetConfigName("config")
 	v.SetConfigType("yaml")
@@ -67,23 +383,77 @@ func Load(configPaths ...string) (*Config, error) {
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
+	// Settings may also be read from the file an APP_*_FILE variable names
+	files, problems := applyFileEnv(v)
+
 	// Create a new config struct
 	cfg := &Config{}
 
 	// Unmarshal the config into the struct
-	if err := v.Unmarshal(cfg); err != nil {
+	err := v.Unmarshal(cfg, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		secretDecodeHook(),
+	)))
+	if err != nil {
 		return nil, fmt.Errorf("unable to decode config into struct: %s", err)
 	}
+	l := &loaded{cfg: cfg, v: v, files: files}
 
-	// Ensure database path exists
-	if cfg.Database.SQLiteDBPath != "" {
-		dir := filepath.Dir(cfg.Database.SQLiteDBPath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return nil, fmt.Errorf("unable to create database directory: %s", err)
+	// Read secrets given as file:// or env: references
+	secretFiles, secretProblems := resolveSecrets(cfg)
+	l.files = append(l.files, secretFiles...)
+	for _, p := range secretProblems {
+		p.Source = sourceOf(v, p.Key)
+		problems = append(problems, p)
+	}
+
+	// Reject typos in the config file and invalid values, reporting where
+	// each offending value came from
+	if file := v.ConfigFileUsed(); file != "" {
+		unknown, err := unknownKeys(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading config file: %s", err)
 		}
+		problems = append(problems, unknown...)
+	}
+	for _, p := range cfg.problems() {
+		p.Source = sourceOf(v, p.Key)
+		problems = append(problems, p)
+	}
+	if len(problems) > 0 {
+		return l, &ValidationError{Problems: problems}
 	}
 
-	return cfg, nil
+	return l, nil
+}
+
+// applyFileEnv sets every setting whose APP_*_FILE environment variable
+// names a file to the file's contents, following the Docker secrets
+// convention. It returns the files read.
+func applyFileEnv(v *viper.Viper) (files []string, problems []Problem) {
+	for _, k := range schema() {
+		name := envVar(k.name) + "_FILE"
+		path, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if _, ok := os.LookupEnv(envVar(k.name)); ok {
+			problems = append(problems, Problem{Key: k.name, Message: fmt.Sprintf("set by both %s and %s", envVar(k.name), name), Source: "env " + name})
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			problems = append(problems, Problem{Key: k.name, Message: fmt.Sprintf("cannot read file: %v", err), Source: "env " + name})
+			continue
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		files = append(files, path)
+		v.Set(k.name, strings.TrimRight(string(data), "\r\n"))
+	}
+	return files, problems
 }
 
 // setDefaults sets the default values for configuration
@@ -99,7 +469,98 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.write_timeout", 10)
 	v.SetDefault("server.idle_timeout", 15)
 	v.SetDefault("server.host", "0.0.0.0")
+	v.SetDefault("server.listen", []string{})
+	v.SetDefault("server.single_port", false)
+	v.SetDefault("server.timeouts", map[string]int{})
+	v.SetDefault("server.max_concurrent_streams", 0)
+	v.SetDefault("server.max_recv_msg_size_bytes", 4*1024*1024)
+	v.SetDefault("server.max_send_msg_size_bytes", 0)
+	v.SetDefault("server.keepalive.max_connection_age", 3600)
+	v.SetDefault("server.keepalive.max_connection_age_grace", 300)
+	v.SetDefault("server.keepalive.time", 60)
+	v.SetDefault("server.keepalive.timeout", 20)
+	v.SetDefault("server.keepalive.min_time", 5)
+	v.SetDefault("server.keepalive.permit_without_stream", true)
+	v.SetDefault("server.auth.enabled", false)
+	v.SetDefault("server.auth.public_methods", []string{"/grpc.health.v1.Health/Check", "/grpc.health.v1.Health/Watch"})
+	v.SetDefault("server.auth.jwt_secret", "")
+	v.SetDefault("server.auth.api_keys", []string{})
+	v.SetDefault("server.rate_limit.enabled", false)
+	v.SetDefault("server.rate_limit.requests_per_second", 100)
+	v.SetDefault("server.rate_limit.burst", 200)
+	v.SetDefault("server.rate_limit.methods", map[string]int{})
+	v.SetDefault("server.tls.enabled", false)
+	v.SetDefault("server.tls.cert", "")
+	v.SetDefault("server.tls.key", "")
 
 	// Database defaults
 	v.SetDefault("database.sqlite_db_path", "./data/users.db")
+
+	// Health defaults
+	v.SetDefault("health.check_interval", 10)
+	v.SetDefault("health.check_timeout", 2)
+	v.SetDefault("health.min_free_disk_mb", 100)
+
+	// Shutdown defaults
+	v.SetDefault("shutdown.pre_stop_delay", 0)
+	v.SetDefault("shutdown.drain_timeout", 20)
+
+	// Gateway defaults
+	v.SetDefault("gateway.listen", []string{})
+	v.SetDefault("gateway.access_log", true)
+	v.SetDefault("gateway.metrics_path", "/metrics")
+	v.SetDefault("gateway.base_path", "/")
+	v.SetDefault("gateway.trusted_proxies", []string{})
+	v.SetDefault("gateway.upstream.load_balancing", "round_robin")
+	v.SetDefault("gateway.upstream.health_check", true)
+	v.SetDefault("gateway.upstream.health_check_service", "")
+	v.SetDefault("gateway.upstream.timeout", 10)
+	v.SetDefault("gateway.upstream.timeouts", map[string]int{})
+	v.SetDefault("gateway.upstream.retry.max_attempts", 3)
+	v.SetDefault("gateway.upstream.retry.initial_backoff_ms", 100)
+	v.SetDefault("gateway.upstream.retry.max_backoff_ms", 1000)
+	v.SetDefault("gateway.upstream.retry.methods", []string{"GetUser", "ListUsers", "SearchUsers"})
+	v.SetDefault("gateway.upstream.retry.retryable_codes", []string{"UNAVAILABLE"})
+	v.SetDefault("gateway.upstream.circuit_breaker.enabled", true)
+	v.SetDefault("gateway.upstream.circuit_breaker.failure_threshold", 5)
+	v.SetDefault("gateway.upstream.circuit_breaker.open_timeout", 10)
+	v.SetDefault("gateway.upstream.tls.enabled", false)
+	v.SetDefault("gateway.upstream.tls.ca_file", "")
+	v.SetDefault("gateway.upstream.tls.server_name", "")
+	v.SetDefault("gateway.headers.incoming", []string{"Accept-Language", "Traceparent", "Tracestate", "X-B3-*", "X-Api-Key"})
+	v.SetDefault("gateway.headers.outgoing", []string{})
+	v.SetDefault("gateway.cors.allowed_origins", []string{})
+	v.SetDefault("gateway.cors.allowed_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE"})
+	v.SetDefault("gateway.cors.allowed_headers", []string{"Accept", "Accept-Language", "Authorization", "Content-Type", "X-Request-Id", "X-Grpc-Web", "X-User-Agent", "Grpc-Timeout"})
+	v.SetDefault("gateway.cors.exposed_headers", []string{"X-Request-Id", "Grpc-Status", "Grpc-Message"})
+	v.SetDefault("gateway.cors.allow_credentials", false)
+	v.SetDefault("gateway.cors.max_age", 600)
+	v.SetDefault("gateway.compression.enabled", true)
+	v.SetDefault("gateway.compression.min_size_bytes", 1024)
+	v.SetDefault("gateway.compression.encodings", []string{"br", "gzip"})
+	v.SetDefault("gateway.compression.content_types", []string{
+		"application/json", "application/problem+json", "application/javascript",
+		"text/html", "text/css", "text/plain", "text/javascript", "image/svg+xml",
+	})
+	v.SetDefault("gateway.security_headers.enabled", true)
+	v.SetDefault("gateway.security_headers.hsts_max_age", 31536000)
+	v.SetDefault("gateway.security_headers.hsts_include_subdomains", true)
+	v.SetDefault("gateway.security_headers.content_security_policy", "default-src 'none'; frame-ancestors 'none'")
+	v.SetDefault("gateway.security_headers.swagger_content_security_policy",
+		"default-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'none'")
+	v.SetDefault("gateway.security_headers.frame_options", "DENY")
+	v.SetDefault("gateway.security_headers.referrer_policy", "no-referrer")
+
+	// Logging defaults
+	v.SetDefault("logging.outputs", []string{"stderr"})
+	v.SetDefault("logging.rotation.max_size_mb", 100)
+	v.SetDefault("logging.rotation.interval_hours", 24)
+	v.SetDefault("logging.rotation.max_backups", 7)
+	v.SetDefault("logging.sampling.enabled", false)
+	v.SetDefault("logging.sampling.initial", 100)
+	v.SetDefault("logging.sampling.thereafter", 100)
+	v.SetDefault("logging.redact_fields", []string{"email"})
+	v.SetDefault("logging.redact_mode", "mask")
+	v.SetDefault("logging.body_level", "metadata")
+	v.SetDefault("logging.max_payload_bytes", 4096)
 } 
\ No newline at end of file
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Reloadable lists the settings applied without a restart, as keys or key
+// prefixes. Changes to any other setting are ignored until the process
+// restarts.
+var Reloadable = []string{
+	"app.log_level",
+	"server.auth",
+	"server.rate_limit",
+	"server.tls.cert",
+	"server.tls.key",
+	"gateway.cors",
+}
+
+// reloadDelay collapses the burst of events editors produce when saving
+const reloadDelay = 100 * time.Millisecond
+
+// ReloadEvent describes the outcome of reloading the config file
+type ReloadEvent struct {
+	// Applied lists the changed settings that took effect
+	Applied []string
+	// Ignored lists the changed settings that require a restart; they keep
+	// their previous values
+	Ignored []string
+	// Err is set when the file could not be loaded or is invalid, in which
+	// case nothing changes
+	Err error
+}
+
+// Watcher reloads the configuration when the config file or a secret file
+// changes and passes changes of Reloadable settings to subscribers
+type Watcher struct {
+	paths  []string
+	report func(ReloadEvent)
+
+	// reloading serializes reloads
+	reloading sync.Mutex
+
+	mu          sync.Mutex
+	current     *Config
+	subscribers []func(old, updated *Config)
+	timer       *time.Timer
+	files       []string
+	// secrets watches the directories of secret files, which also sees
+	// files replaced by a rename or a symlink swap as on Kubernetes
+	secrets *fsnotify.Watcher
+	dirs    map[string]bool
+}
+
+// Watch starts watching the config file that Load would read and the
+// secret files it refers to, starting from the configuration current
+// returned. report is called after every reload, including failed ones,
+// so the caller can log the outcome.
+func Watch(current *Config, report func(ReloadEvent), configPaths ...string) (*Watcher, error) {
+	w := &Watcher{paths: configPaths, report: report, current: current, dirs: map[string]bool{}}
+
+	l, err := load(configPaths)
+	if l == nil {
+		return nil, err
+	}
+	if file := l.v.ConfigFileUsed(); file != "" {
+		w.files = append(w.files, file)
+		l.v.OnConfigChange(func(fsnotify.Event) { w.schedule() })
+		l.v.WatchConfig()
+	}
+	if err := w.watchFiles(l.files); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Files returns the watched config and secret files
+func (w *Watcher) Files() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string{}, w.files...)
+}
+
+// Current returns the configuration in effect
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Subscribe registers fn to be called with the previous and the new
+// configuration whenever Reloadable settings change. Settings that require
+// a restart have their previous values in both.
+func (w *Watcher) Subscribe(fn func(old, updated *Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// schedule reloads once changes have settled
+func (w *Watcher) schedule() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(reloadDelay, w.reload)
+}
+
+// watchFiles adds the directories of secret files to the watch list
+func (w *Watcher) watchFiles(files []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		if w.dirs[dir] {
+			continue
+		}
+		if w.secrets == nil {
+			secrets, err := fsnotify.NewWatcher()
+			if err != nil {
+				return err
+			}
+			w.secrets = secrets
+			go w.watchSecrets(secrets)
+		}
+		if err := w.secrets.Add(dir); err != nil {
+			return fmt.Errorf("cannot watch %s: %w", dir, err)
+		}
+		w.dirs[dir] = true
+		w.files = append(w.files, file)
+	}
+	return nil
+}
+
+func (w *Watcher) watchSecrets(secrets *fsnotify.Watcher) {
+	for {
+		select {
+		case _, ok := <-secrets.Events:
+			if !ok {
+				return
+			}
+			// Reloading compares every setting, so unrelated files in the
+			// same directory cause no changes
+			w.schedule()
+		case _, ok := <-secrets.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+func (w *Watcher) reload() {
+	w.reloading.Lock()
+	defer w.reloading.Unlock()
+
+	l, err := load(w.paths)
+	if err != nil {
+		w.report(ReloadEvent{Err: err})
+		return
+	}
+	// Secrets may now refer to files in other directories. A directory
+	// that cannot be watched only means later changes there are missed.
+	_ = w.watchFiles(l.files)
+	updated := l.cfg
+
+	w.mu.Lock()
+	old := w.current
+	applied, ignored := merge(old, updated)
+	var subscribers []func(old, updated *Config)
+	if len(applied) > 0 {
+		w.current = updated
+		subscribers = append(subscribers, w.subscribers...)
+	}
+	w.mu.Unlock()
+
+	if len(applied) == 0 && len(ignored) == 0 {
+		return
+	}
+	// Report first, so the outcome is logged before the level may change
+	w.report(ReloadEvent{Applied: applied, Ignored: ignored})
+	for _, fn := range subscribers {
+		fn(old, updated)
+	}
+}
+
+// merge compares every setting of old and updated. Changed settings that
+// require a restart are reset to their old values in updated.
+func merge(old, updated *Config) (applied, ignored []string) {
+	oldRoot := reflect.ValueOf(old).Elem()
+	newRoot := reflect.ValueOf(updated).Elem()
+	for _, k := range schema() {
+		before, after := oldRoot.FieldByIndex(k.index), newRoot.FieldByIndex(k.index)
+		if reflect.DeepEqual(before.Interface(), after.Interface()) {
+			continue
+		}
+		if reloadable(k.name) {
+			applied = append(applied, k.name)
+			continue
+		}
+		ignored = append(ignored, k.name)
+		after.Set(before)
+	}
+	return applied, ignored
+}
+
+func reloadable(key string) bool {
+	for _, r := range Reloadable {
+		if key == r || strings.HasPrefix(key, r+".") {
+			return true
+		}
+	}
+	return false
+}
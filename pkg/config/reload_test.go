@@ -0,0 +1,141 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadable(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"app.log_level", true},
+		{"app.name", false},
+		{"server.auth.enabled", true},
+		{"server.auth.jwt_secret", true},
+		{"server.rate_limit.methods", true},
+		{"server.tls.cert", true},
+		{"server.tls.key", true},
+		{"server.tls.enabled", false},
+		{"server.port", false},
+		{"gateway.cors.allowed_origins", true},
+		{"gateway.cors_extra", false},
+		{"database.sqlite_db_path", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.want, reloadable(tt.key))
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		applied []string
+		ignored []string
+		check   func(t *testing.T, merged *Config)
+	}{
+		{"no changes", func(c *Config) {}, nil, nil, nil},
+		{"reloadable setting", func(c *Config) { c.App.LogLevel = "debug" }, []string{"app.log_level"}, nil,
+			func(t *testing.T, merged *Config) { assert.Equal(t, "debug", merged.App.LogLevel) }},
+		{"restart setting is reset", func(c *Config) { c.Server.Port = 1234 }, nil, []string{"server.port"},
+			func(t *testing.T, merged *Config) { assert.Equal(t, 50051, merged.Server.Port) }},
+		{"map setting is reset", func(c *Config) { c.Server.Timeouts = map[string]int{"listusers": 30} }, nil, []string{"server.timeouts"},
+			func(t *testing.T, merged *Config) { assert.Empty(t, merged.Server.Timeouts) }},
+		{"mixed changes in schema order", func(c *Config) {
+			c.Gateway.CORS.AllowedOrigins = []string{"https://example.com"}
+			c.Database.SQLiteDBPath = "/var/lib/app/users.db"
+			c.Server.TLS.Enabled = true
+			c.Server.TLS.Cert = NewSecret("renewed")
+			c.Server.Auth.PublicMethods = nil
+		}, []string{"server.auth.public_methods", "server.tls.cert", "gateway.cors.allowed_origins"},
+			[]string{"server.tls.enabled", "database.sqlite_db_path"},
+			func(t *testing.T, merged *Config) {
+				assert.Equal(t, []string{"https://example.com"}, merged.Gateway.CORS.AllowedOrigins)
+				assert.Equal(t, "renewed", merged.Server.TLS.Cert.Value())
+				assert.False(t, merged.Server.TLS.Enabled)
+				assert.Equal(t, "./data/users.db", merged.Database.SQLiteDBPath)
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old, updated := defaultConfig(t), defaultConfig(t)
+			tt.modify(updated)
+
+			applied, ignored := merge(old, updated)
+			assert.Equal(t, tt.applied, applied)
+			assert.Equal(t, tt.ignored, ignored)
+			if tt.check != nil {
+				tt.check(t, updated)
+			}
+		})
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	secretFile := filepath.Join(dir, "jwt")
+	writeConfig := func(level string, port int) {
+		t.Helper()
+		data := "app:\n  log_level: " + level + "\nserver:\n  port: " + strconv.Itoa(port) +
+			"\n  auth:\n    jwt_secret: file://" + secretFile + "\n"
+		require.NoError(t, os.WriteFile(configFile, []byte(data), 0o644))
+	}
+	require.NoError(t, os.WriteFile(secretFile, []byte("0123456789abcdef0123456789abcdef\n"), 0o600))
+	writeConfig("info", 1234)
+
+	l, err := load([]string{dir})
+	require.NoError(t, err)
+	events := make(chan ReloadEvent, 10)
+	w, err := Watch(l.cfg, func(e ReloadEvent) { events <- e }, dir)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{configFile, secretFile}, w.Files())
+
+	updates := make(chan *Config, 10)
+	w.Subscribe(func(old, updated *Config) { updates <- updated })
+
+	next := func() ReloadEvent {
+		t.Helper()
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("no reload")
+			return ReloadEvent{}
+		}
+	}
+
+	// Restart settings keep their values; reloadable ones are applied
+	writeConfig("debug", 4321)
+	e := next()
+	require.NoError(t, e.Err)
+	assert.Equal(t, []string{"app.log_level"}, e.Applied)
+	assert.Equal(t, []string{"server.port"}, e.Ignored)
+	updated := <-updates
+	assert.Equal(t, "debug", updated.App.LogLevel)
+	assert.Equal(t, 1234, updated.Server.Port)
+	assert.Same(t, updated, w.Current())
+
+	// A rotated secret file is reloaded like the config file
+	require.NoError(t, os.WriteFile(secretFile, []byte("fedcba9876543210fedcba9876543210\n"), 0o600))
+	e = next()
+	require.NoError(t, e.Err)
+	assert.Equal(t, []string{"server.auth.jwt_secret"}, e.Applied)
+	assert.Equal(t, "fedcba9876543210fedcba9876543210", (<-updates).Server.Auth.JWTSecret.Value())
+
+	// An invalid file changes nothing
+	writeConfig("verbose", 1234)
+	e = next()
+	assert.Error(t, e.Err)
+	assert.Equal(t, "debug", w.Current().App.LogLevel)
+}
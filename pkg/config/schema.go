@@ -0,0 +1,275 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// setting is one effective configuration value and where it came from
+type setting struct {
+	key    string
+	value  string
+	source string
+}
+
+// Sources of settings other than environment variables, which are reported
+// as "env" followed by the variable name
+const (
+	sourceFile    = "file"
+	sourceDefault = "default"
+)
+
+// secretWords mark settings whose values are masked when printed
+var secretWords = []string{"password", "secret", "token", "api_key", "apikey", "private_key", "credentials"}
+
+// isSecret reports whether key names a setting whose value must not be shown
+func isSecret(key string) bool {
+	name := key[strings.LastIndex(key, ".")+1:]
+	for _, w := range secretWords {
+		if strings.Contains(name, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaKey is a setting of Config. Open keys are maps whose own keys are
+// free-form, such as gateway.upstream.timeouts.
+type schemaKey struct {
+	name  string
+	index []int
+	open  bool
+}
+
+// schema lists the settings of Config in declaration order
+func schema() []schemaKey {
+	var keys []schemaKey
+	var walk func(t reflect.Type, prefix string, index []int)
+	walk = func(t reflect.Type, prefix string, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("mapstructure")
+			if tag == "" || tag == "-" {
+				continue
+			}
+			name := prefix + tag
+			idx := append(append([]int{}, index...), i)
+			if f.Type.Kind() == reflect.Struct && f.Type != secretType {
+				walk(f.Type, name+".", idx)
+				continue
+			}
+			keys = append(keys, schemaKey{name: name, index: idx, open: f.Type.Kind() == reflect.Map})
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "", nil)
+	return keys
+}
+
+// envVar returns the environment variable that overrides key
+func envVar(key string) string {
+	return "APP_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// source describes where viper took key's value from
+func source(v *viper.Viper, key string) string {
+	if _, ok := os.LookupEnv(envVar(key)); ok {
+		return "env " + envVar(key)
+	}
+	if _, ok := os.LookupEnv(envVar(key) + "_FILE"); ok {
+		return "env " + envVar(key) + "_FILE"
+	}
+	if v.InConfig(key) {
+		return sourceFile
+	}
+	return sourceDefault
+}
+
+// sourceOf returns the source of a problem's key, which may point inside a
+// list or map setting such as logging.outputs[1]
+func sourceOf(v *viper.Viper, key string) string {
+	if i := strings.IndexByte(key, '['); i >= 0 {
+		key = key[:i]
+	}
+	for _, k := range schema() {
+		if k.open && strings.HasPrefix(key, k.name+".") {
+			key = k.name
+			break
+		}
+	}
+	if src := source(v, key); src != sourceFile {
+		return src
+	}
+	return "file " + v.ConfigFileUsed()
+}
+
+// settings returns every setting of cfg with secrets masked
+func settings(v *viper.Viper, cfg *Config) []setting {
+	root := reflect.ValueOf(cfg).Elem()
+	var out []setting
+	for _, k := range schema() {
+		field := root.FieldByIndex(k.index)
+		s := setting{
+			key:    k.name,
+			value:  formatValue(field),
+			source: source(v, k.name),
+		}
+		// Secret settings mask themselves; plain ones named like secrets
+		// are masked too
+		if !holdsSecrets(field.Type()) && isSecret(k.name) && s.value != `""` {
+			s.value = "****"
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// holdsSecrets reports whether t is a Secret or a list of them
+func holdsSecrets(t reflect.Type) bool {
+	return t == secretType || (t.Kind() == reflect.Slice && t.Elem() == secretType)
+}
+
+// formatValue renders a setting compactly in YAML flow style. Secrets are
+// masked, showing only the reference they were read from.
+func formatValue(v reflect.Value) string {
+	if v.Type() == secretType {
+		s := v.Interface().(Secret)
+		switch {
+		case !s.IsSet():
+			return `""`
+		case s.ref != "":
+			return "**** (" + s.ref + ")"
+		default:
+			return "****"
+		}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		if v.String() == "" {
+			return `""`
+		}
+		return v.String()
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatValue(v.Index(i))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		items := make([]string, len(keys))
+		for i, k := range keys {
+			items[i] = fmt.Sprintf("%s: %s", k.String(), formatValue(v.MapIndex(k)))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	case reflect.Struct:
+		var items []string
+		for i := 0; i < v.NumField(); i++ {
+			tag := v.Type().Field(i).Tag.Get("mapstructure")
+			items = append(items, fmt.Sprintf("%s: %s", tag, formatValue(v.Field(i))))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// unknownKeys reports settings in the config file that Config does not
+// have, which are usually typos that would otherwise be ignored
+func unknownKeys(path string) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	known := map[string]bool{}
+	sections := map[string]bool{}
+	var names []string
+	for _, k := range schema() {
+		known[k.name] = true
+		names = append(names, k.name)
+		for i := range k.name {
+			if k.name[i] == '.' && !sections[k.name[:i]] {
+				sections[k.name[:i]] = true
+				names = append(names, k.name[:i])
+			}
+		}
+	}
+
+	var problems []Problem
+	var walk func(m map[string]interface{}, prefix string)
+	walk = func(m map[string]interface{}, prefix string) {
+		for name, value := range m {
+			key := prefix + strings.ToLower(name)
+			switch {
+			case known[key]:
+			case sections[key]:
+				if sub, ok := value.(map[string]interface{}); ok {
+					walk(sub, key+".")
+				}
+			default:
+				msg := "unknown setting"
+				if s := closest(key, names); s != "" {
+					msg += "; did you mean " + s + "?"
+				}
+				problems = append(problems, Problem{Key: key, Message: msg, Source: "file " + path})
+			}
+		}
+	}
+	walk(doc, "")
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Key < problems[j].Key })
+	return problems, nil
+}
+
+// closest returns the known key nearest to key, if any is near enough to
+// be a likely typo: a small edit away, a truncation, or the same name in
+// another section
+func closest(key string, known []string) string {
+	best, bestDist := "", 3
+	for _, k := range known {
+		if d := editDistance(key, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	if best != "" {
+		return best
+	}
+	name := key[strings.LastIndex(key, ".")+1:]
+	for _, k := range known {
+		if strings.HasPrefix(k, key+"_") || strings.HasSuffix(k, "."+name) {
+			return k
+		}
+	}
+	return ""
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
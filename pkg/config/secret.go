@@ -0,0 +1,146 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// Secret reference prefixes. A secret setting may hold the value itself or
+// refer to a file or environment variable holding it.
+const (
+	secretFilePrefix = "file://"
+	secretEnvPrefix  = "env:"
+)
+
+const redacted = "[REDACTED]"
+
+// Secret is a sensitive setting, such as a signing key. It redacts itself
+// when printed, logged or marshaled; Value returns the secret.
+type Secret struct {
+	value string
+	// ref is the file:// or env: reference the value was read from
+	ref string
+}
+
+// NewSecret returns a secret holding value
+func NewSecret(value string) Secret {
+	return Secret{value: value}
+}
+
+// Value returns the secret value
+func (s Secret) Value() string {
+	return s.value
+}
+
+// IsSet reports whether the secret has a value
+func (s Secret) IsSet() bool {
+	return s.value != ""
+}
+
+// String implements fmt.Stringer without revealing the value
+func (s Secret) String() string {
+	if s.value == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString keeps %#v from printing the value
+func (s Secret) GoString() string {
+	return fmt.Sprintf("config.Secret(%q)", s.String())
+}
+
+// MarshalJSON implements json.Marshaler without revealing the value
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// MarshalText implements encoding.TextMarshaler without revealing the
+// value, which also covers YAML and zap's reflection-based encoding
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+var secretType = reflect.TypeOf(Secret{})
+
+// secretDecodeHook decodes settings into Secret fields. References are kept
+// as they are until resolveSecrets reads them.
+func secretDecodeHook() mapstructure.DecodeHookFuncType {
+	return func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if to != secretType || from.Kind() != reflect.String {
+			return data, nil
+		}
+		return Secret{value: data.(string)}, nil
+	}
+}
+
+// resolveSecrets reads the secrets of cfg that are file:// or env:
+// references, including those in lists. It returns the files read, so they
+// can be watched for changes, and a problem for every reference that cannot
+// be resolved, leaving that secret empty.
+func resolveSecrets(cfg *Config) (files []string, problems []Problem) {
+	root := reflect.ValueOf(cfg).Elem()
+	for _, k := range schema() {
+		field := root.FieldByIndex(k.index)
+		switch {
+		case field.Type() == secretType:
+			file, problem := resolveSecret(k.name, field)
+			files, problems = appendResolved(files, problems, file, problem)
+		case field.Kind() == reflect.Slice && field.Type().Elem() == secretType:
+			for i := 0; i < field.Len(); i++ {
+				file, problem := resolveSecret(fmt.Sprintf("%s[%d]", k.name, i), field.Index(i))
+				files, problems = appendResolved(files, problems, file, problem)
+			}
+		}
+	}
+	return files, problems
+}
+
+// resolveSecret resolves the secret held in field, returning the file it
+// was read from, if any, or the problem with its reference
+func resolveSecret(key string, field reflect.Value) (string, *Problem) {
+	s := field.Interface().(Secret)
+	var file string
+	switch {
+	case strings.HasPrefix(s.value, secretFilePrefix):
+		path := strings.TrimPrefix(s.value, secretFilePrefix)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			field.Set(reflect.ValueOf(Secret{ref: s.value}))
+			return "", &Problem{Key: key, Message: fmt.Sprintf("cannot read secret file: %v", err)}
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		file = path
+		s = Secret{value: strings.TrimRight(string(data), "\r\n"), ref: s.value}
+	case strings.HasPrefix(s.value, secretEnvPrefix):
+		name := strings.TrimPrefix(s.value, secretEnvPrefix)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			field.Set(reflect.ValueOf(Secret{ref: s.value}))
+			return "", &Problem{Key: key, Message: fmt.Sprintf("secret environment variable %s is not set", name)}
+		}
+		s = Secret{value: value, ref: s.value}
+	default:
+		return "", nil
+	}
+	field.Set(reflect.ValueOf(s))
+	return file, nil
+}
+
+func appendResolved(files []string, problems []Problem, file string, problem *Problem) ([]string, []Problem) {
+	if file != "" {
+		files = append(files, file)
+	}
+	if problem != nil {
+		problems = append(problems, *problem)
+	}
+	return files, problems
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("from-file-0123456789\n"), 0o600))
+	t.Setenv("TEST_API_KEY", "from-env-0123456789")
+	missingFile := filepath.Join(dir, "missing")
+
+	tests := []struct {
+		name     string
+		auth     AuthConfig
+		jwt      string
+		keys     []string
+		files    []string
+		problems []string
+	}{
+		{"literal values", AuthConfig{
+			JWTSecret: NewSecret("literal"),
+			APIKeys:   []Secret{NewSecret("literal-key")},
+		}, "literal", []string{"literal-key"}, nil, nil},
+		{"file reference", AuthConfig{JWTSecret: NewSecret("file://" + keyFile)}, "from-file-0123456789", nil, []string{keyFile}, nil},
+		{"env reference", AuthConfig{JWTSecret: NewSecret("env:TEST_API_KEY")}, "from-env-0123456789", nil, nil, nil},
+		{"references in a list", AuthConfig{
+			APIKeys: []Secret{NewSecret("literal-key"), NewSecret("file://" + keyFile), NewSecret("env:TEST_API_KEY")},
+		}, "", []string{"literal-key", "from-file-0123456789", "from-env-0123456789"}, []string{keyFile}, nil},
+		{"unresolved references", AuthConfig{
+			JWTSecret: NewSecret("file://" + missingFile),
+			APIKeys:   []Secret{NewSecret("literal-key"), NewSecret("env:TEST_MISSING_KEY")},
+		}, "", []string{"literal-key", ""}, nil, []string{"server.auth.jwt_secret", "server.auth.api_keys[1]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Server: ServerConfig{Auth: tt.auth}}
+			files, problems := resolveSecrets(cfg)
+
+			assert.Equal(t, tt.files, files)
+			var keys []string
+			for _, p := range problems {
+				keys = append(keys, p.Key)
+			}
+			assert.Equal(t, tt.problems, keys)
+
+			assert.Equal(t, tt.jwt, cfg.Server.Auth.JWTSecret.Value())
+			var values []string
+			for _, k := range cfg.Server.Auth.APIKeys {
+				values = append(values, k.Value())
+			}
+			assert.Equal(t, tt.keys, values)
+		})
+	}
+}
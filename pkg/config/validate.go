@@ -0,0 +1,292 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/codes"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/listener"
+)
+
+// Environments are the accepted values of app.environment
+var Environments = []string{"development", "staging", "production"}
+
+// Problem describes an invalid or unknown setting
+type Problem struct {
+	// Key is the setting's dotted name, e.g. server.port
+	Key     string
+	Message string
+	// Source is where the value came from: a file, an environment
+	// variable or the default
+	Source string
+}
+
+func (p Problem) String() string {
+	if p.Source == "" {
+		return fmt.Sprintf("%s: %s", p.Key, p.Message)
+	}
+	return fmt.Sprintf("%s: %s (from %s)", p.Key, p.Message, p.Source)
+}
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	if len(e.Problems) == 1 {
+		b.WriteString("invalid configuration (1 problem):")
+	} else {
+		fmt.Fprintf(&b, "invalid configuration (%d problems):", len(e.Problems))
+	}
+	for _, p := range e.Problems {
+		b.WriteString("\n  ")
+		b.WriteString(p.String())
+	}
+	return b.String()
+}
+
+// Validate checks ranges, required settings and enumerated values. The
+// returned error is a *ValidationError listing every problem.
+func (c *Config) Validate() error {
+	problems := c.problems()
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// validator collects problems
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) add(key, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) required(key, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(key, "is required")
+	}
+}
+
+func (v *validator) min(key string, value, min int) {
+	if value < min {
+		v.add(key, "must be at least %d, got %d", min, value)
+	}
+}
+
+func (v *validator) between(key string, value, min, max int) {
+	if value < min || value > max {
+		v.add(key, "must be between %d and %d, got %d", min, max, value)
+	}
+}
+
+// oneOf checks an enumerated value. Every enumerated setting is
+// case-insensitive, so its consumers must compare it the same way.
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return
+		}
+	}
+	v.add(key, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+}
+
+// notDirectory checks that a file path, if it exists, is not a directory
+func (v *validator) notDirectory(key, path string) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		v.add(key, "%s is a directory, expected a file path", path)
+	}
+}
+
+func (v *validator) path(key, value string) {
+	if value != "" && !strings.HasPrefix(value, "/") {
+		v.add(key, "must start with /, got %q", value)
+	}
+}
+
+// listen checks listen addresses, which are TCP addresses or Unix sockets
+// with absolute paths and an optional mode
+func (v *validator) listen(key string, addrs []string) {
+	for i, addr := range addrs {
+		if _, err := listener.Parse(addr); err != nil {
+			v.add(fmt.Sprintf("%s[%d]", key, i), "%v", err)
+		}
+	}
+}
+
+func (c *Config) problems() []Problem {
+	v := &validator{}
+
+	v.required("app.name", c.App.Name)
+	v.oneOf("app.environment", c.App.Environment, Environments...)
+	// ParseLevel ignores case like oneOf
+	if _, err := zapcore.ParseLevel(c.App.LogLevel); err != nil {
+		v.add("app.log_level", "must be one of debug, info, warn, error, dpanic, panic, fatal, got %q", c.App.LogLevel)
+	}
+
+	v.between("server.port", c.Server.Port, 1, 65535)
+	v.min("server.read_timeout", c.Server.ReadTimeout, 0)
+	v.min("server.write_timeout", c.Server.WriteTimeout, 0)
+	v.min("server.idle_timeout", c.Server.IdleTimeout, 0)
+	for method, timeout := range c.Server.Timeouts {
+		v.min("server.timeouts."+method, timeout, 0)
+	}
+	if c.Server.Host != "" && strings.ContainsAny(c.Server.Host, ":/ ") && net.ParseIP(c.Server.Host) == nil {
+		v.add("server.host", "must be a host name or IP address without a port, got %q", c.Server.Host)
+	}
+	v.listen("server.listen", c.Server.Listen)
+	v.min("server.max_concurrent_streams", c.Server.MaxConcurrentStreams, 0)
+	v.min("server.max_recv_msg_size_bytes", c.Server.MaxRecvMsgSizeBytes, 0)
+	v.min("server.max_send_msg_size_bytes", c.Server.MaxSendMsgSizeBytes, 0)
+	v.min("server.keepalive.max_connection_age", c.Server.Keepalive.MaxConnectionAge, 0)
+	v.min("server.keepalive.max_connection_age_grace", c.Server.Keepalive.MaxConnectionAgeGrace, 0)
+	v.min("server.keepalive.time", c.Server.Keepalive.Time, 0)
+	v.min("server.keepalive.timeout", c.Server.Keepalive.Timeout, 0)
+	v.min("server.keepalive.min_time", c.Server.Keepalive.MinTime, 0)
+	for i, m := range c.Server.Auth.PublicMethods {
+		if !strings.HasPrefix(m, "/") {
+			v.add(fmt.Sprintf("server.auth.public_methods[%d]", i), "must be a full method name such as /user.UserService/GetUser, got %q", m)
+		}
+	}
+	if c.Server.Auth.JWTSecret.IsSet() && len(c.Server.Auth.JWTSecret.Value()) < 32 {
+		v.add("server.auth.jwt_secret", "must be at least 32 bytes, got %d", len(c.Server.Auth.JWTSecret.Value()))
+	}
+	for i, key := range c.Server.Auth.APIKeys {
+		// A reference that could not be read is reported already
+		if (key.ref == "" || key.IsSet()) && len(key.Value()) < 16 {
+			v.add(fmt.Sprintf("server.auth.api_keys[%d]", i), "must be at least 16 bytes, got %d", len(key.Value()))
+		}
+	}
+	if t := c.Server.TLS; t.Enabled {
+		// References that could not be read are reported already
+		if t.Cert.ref == "" && !t.Cert.IsSet() {
+			v.add("server.tls.cert", "is required when server.tls is enabled")
+		}
+		if t.Key.ref == "" && !t.Key.IsSet() {
+			v.add("server.tls.key", "is required when server.tls is enabled")
+		}
+		if t.Cert.IsSet() && t.Key.IsSet() {
+			if _, err := tls.X509KeyPair([]byte(t.Cert.Value()), []byte(t.Key.Value())); err != nil {
+				v.add("server.tls.cert", "is not a valid certificate for server.tls.key: %v", err)
+			}
+		}
+	}
+	if c.Server.RateLimit.Enabled {
+		v.min("server.rate_limit.requests_per_second", c.Server.RateLimit.RequestsPerSecond, 1)
+		v.min("server.rate_limit.burst", c.Server.RateLimit.Burst, 1)
+		for method, rps := range c.Server.RateLimit.Methods {
+			v.min("server.rate_limit.methods."+method, rps, 1)
+		}
+	}
+
+	v.required("database.sqlite_db_path", c.Database.SQLiteDBPath)
+	v.notDirectory("database.sqlite_db_path", c.Database.SQLiteDBPath)
+
+	v.min("health.check_interval", c.Health.CheckInterval, 1)
+	v.min("health.check_timeout", c.Health.CheckTimeout, 1)
+	if c.Health.CheckTimeout > c.Health.CheckInterval && c.Health.CheckInterval >= 1 {
+		v.add("health.check_timeout", "must not exceed health.check_interval (%d), got %d", c.Health.CheckInterval, c.Health.CheckTimeout)
+	}
+	v.min("health.min_free_disk_mb", c.Health.MinFreeDiskMB, 0)
+
+	v.min("shutdown.pre_stop_delay", c.Shutdown.PreStopDelay, 0)
+	v.min("shutdown.drain_timeout", c.Shutdown.DrainTimeout, 0)
+
+	c.Logging.validate(v)
+	c.Gateway.validate(v)
+	return v.problems
+}
+
+func (l *LoggingConfig) validate(v *validator) {
+	if l.Format != "" {
+		v.oneOf("logging.format", l.Format, "json", "console")
+	}
+	for i, out := range l.Outputs {
+		key := fmt.Sprintf("logging.outputs[%d]", i)
+		v.required(key, out)
+		if out != "stderr" && out != "stdout" {
+			v.notDirectory(key, out)
+		}
+	}
+	v.min("logging.rotation.max_size_mb", l.Rotation.MaxSizeMB, 0)
+	v.min("logging.rotation.interval_hours", l.Rotation.IntervalHours, 0)
+	v.min("logging.rotation.max_backups", l.Rotation.MaxBackups, 0)
+	if l.Sampling.Enabled {
+		v.min("logging.sampling.initial", l.Sampling.Initial, 1)
+		v.min("logging.sampling.thereafter", l.Sampling.Thereafter, 1)
+	}
+	v.oneOf("logging.redact_mode", l.RedactMode, "mask", "hash")
+	v.oneOf("logging.body_level", l.BodyLevel, "none", "metadata", "full")
+	for i, m := range l.MethodBodyLevels {
+		key := fmt.Sprintf("logging.method_body_levels[%d]", i)
+		if !strings.HasPrefix(m.Method, "/") {
+			v.add(key+".method", "must be a full method name such as /user.UserService/GetUser, got %q", m.Method)
+		}
+		v.oneOf(key+".level", m.Level, "none", "metadata", "full")
+	}
+	v.min("logging.max_payload_bytes", l.MaxPayloadBytes, 0)
+}
+
+func (g *GatewayConfig) validate(v *validator) {
+	v.listen("gateway.listen", g.Listen)
+	v.path("gateway.metrics_path", g.MetricsPath)
+	v.required("gateway.base_path", g.BasePath)
+	v.path("gateway.base_path", g.BasePath)
+	for i, p := range g.TrustedProxies {
+		if net.ParseIP(p) == nil {
+			if _, _, err := net.ParseCIDR(p); err != nil {
+				v.add(fmt.Sprintf("gateway.trusted_proxies[%d]", i), "must be an IP address or CIDR range, got %q", p)
+			}
+		}
+	}
+
+	u := g.Upstream
+	v.oneOf("gateway.upstream.load_balancing", u.LoadBalancing, "pick_first", "round_robin")
+	v.min("gateway.upstream.timeout", u.Timeout, 0)
+	for method, timeout := range u.Timeouts {
+		v.min("gateway.upstream.timeouts."+method, timeout, 0)
+	}
+	v.min("gateway.upstream.retry.max_attempts", u.Retry.MaxAttempts, 1)
+	if u.Retry.MaxAttempts > 1 {
+		v.min("gateway.upstream.retry.initial_backoff_ms", u.Retry.InitialBackoffMs, 1)
+		if u.Retry.MaxBackoffMs < u.Retry.InitialBackoffMs {
+			v.add("gateway.upstream.retry.max_backoff_ms", "must be at least initial_backoff_ms (%d), got %d", u.Retry.InitialBackoffMs, u.Retry.MaxBackoffMs)
+		}
+	}
+	for i, name := range u.Retry.RetryableCodes {
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(fmt.Sprintf("%q", strings.ToUpper(name)))); err != nil {
+			v.add(fmt.Sprintf("gateway.upstream.retry.retryable_codes[%d]", i), "must be a gRPC status code such as UNAVAILABLE, got %q", name)
+		}
+	}
+	if u.TLS.Enabled && u.TLS.CAFile != "" {
+		if _, err := os.Stat(u.TLS.CAFile); err != nil {
+			v.add("gateway.upstream.tls.ca_file", "%v", err)
+		} else {
+			v.notDirectory("gateway.upstream.tls.ca_file", u.TLS.CAFile)
+		}
+	}
+	if u.CircuitBreaker.Enabled {
+		v.min("gateway.upstream.circuit_breaker.failure_threshold", u.CircuitBreaker.FailureThreshold, 1)
+		v.min("gateway.upstream.circuit_breaker.open_timeout", u.CircuitBreaker.OpenTimeout, 1)
+	}
+
+	v.min("gateway.cors.max_age", g.CORS.MaxAge, 0)
+	v.min("gateway.compression.min_size_bytes", g.Compression.MinSizeBytes, 0)
+	for i, enc := range g.Compression.Encodings {
+		v.oneOf(fmt.Sprintf("gateway.compression.encodings[%d]", i), enc, "br", "gzip")
+	}
+	v.min("gateway.security_headers.hsts_max_age", g.SecurityHeaders.HSTSMaxAge, 0)
+	if g.SecurityHeaders.FrameOptions != "" {
+		v.oneOf("gateway.security_headers.frame_options", g.SecurityHeaders.FrameOptions, "DENY", "SAMEORIGIN")
+	}
+}
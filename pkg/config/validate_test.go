@@ -0,0 +1,175 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// defaultConfig loads the defaults, without a config file
+func defaultConfig(t *testing.T) *Config {
+	t.Helper()
+	l, err := load([]string{t.TempDir()})
+	require.NoError(t, err)
+	return l.cfg
+}
+
+func TestProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		keys   []string
+	}{
+		{"defaults", func(c *Config) {}, nil},
+		{"enums ignore case", func(c *Config) {
+			c.App.Environment = "Production"
+			c.App.LogLevel = "Info"
+			c.Logging.Format = "JSON"
+			c.Logging.RedactMode = "Hash"
+			c.Logging.BodyLevel = "FULL"
+			c.Logging.MethodBodyLevels = []MethodBodyLevel{{Method: "/user.UserService/GetUser", Level: "None"}}
+			c.Gateway.Upstream.LoadBalancing = "Pick_First"
+			c.Gateway.Upstream.Retry.RetryableCodes = []string{"unavailable"}
+		}, nil},
+		{"unknown enum values", func(c *Config) {
+			c.App.Environment = "prod"
+			c.App.LogLevel = "verbose"
+			c.Logging.Format = "text"
+			c.Logging.RedactMode = "drop"
+			c.Logging.BodyLevel = "all"
+			c.Gateway.Upstream.LoadBalancing = "least_request"
+			c.Gateway.Upstream.Retry.RetryableCodes = []string{"UNAVAILABLE", "TIMEOUT"}
+		}, []string{
+			"app.environment", "app.log_level", "logging.format", "logging.redact_mode",
+			"logging.body_level", "gateway.upstream.load_balancing", "gateway.upstream.retry.retryable_codes[1]",
+		}},
+		{"ranges", func(c *Config) {
+			c.Server.Port = 70000
+			c.Server.ReadTimeout = -1
+			c.Server.Timeouts = map[string]int{"getuser": -5}
+			c.Logging.MaxPayloadBytes = -1
+		}, []string{"server.port", "server.read_timeout", "server.timeouts.getuser", "logging.max_payload_bytes"}},
+		{"required", func(c *Config) {
+			c.App.Name = " "
+			c.Database.SQLiteDBPath = ""
+			c.Gateway.BasePath = ""
+		}, []string{"app.name", "database.sqlite_db_path", "gateway.base_path"}},
+		{"host with port", func(c *Config) { c.Server.Host = "localhost:50051" }, []string{"server.host"}},
+		{"ipv6 host", func(c *Config) { c.Server.Host = "::1" }, nil},
+		{"method names", func(c *Config) {
+			c.Server.Auth.PublicMethods = []string{"/user.UserService/GetUser", "GetUser"}
+			c.Logging.MethodBodyLevels = []MethodBodyLevel{{Method: "CreateUser", Level: "loud"}}
+		}, []string{"server.auth.public_methods[1]", "logging.method_body_levels[0].method", "logging.method_body_levels[0].level"}},
+		{"short secrets", func(c *Config) {
+			c.Server.Auth.JWTSecret = NewSecret("too short")
+			c.Server.Auth.APIKeys = []Secret{NewSecret("0123456789abcdef"), NewSecret("short")}
+		}, []string{"server.auth.jwt_secret", "server.auth.api_keys[1]"}},
+		{"tls without certificate", func(c *Config) { c.Server.TLS.Enabled = true }, []string{"server.tls.cert", "server.tls.key"}},
+		{"tls with invalid certificate", func(c *Config) {
+			c.Server.TLS = TLSConfig{Enabled: true, Cert: NewSecret("not a certificate"), Key: NewSecret("not a key")}
+		}, []string{"server.tls.cert"}},
+		{"tls certificate only checked when enabled", func(c *Config) { c.Server.TLS.Cert = NewSecret("not a certificate") }, nil},
+		{"upstream ca file", func(c *Config) {
+			c.Gateway.Upstream.TLS = UpstreamTLSConfig{Enabled: true, CAFile: filepath.Join(os.TempDir(), "missing-ca.pem")}
+		}, []string{"gateway.upstream.tls.ca_file"}},
+		{"rate limits only checked when enabled", func(c *Config) {
+			c.Server.RateLimit.Enabled = false
+			c.Server.RateLimit.RequestsPerSecond = 0
+		}, nil},
+		{"rate limits", func(c *Config) {
+			c.Server.RateLimit.Enabled = true
+			c.Server.RateLimit.RequestsPerSecond = 0
+			c.Server.RateLimit.Burst = 0
+		}, []string{"server.rate_limit.requests_per_second", "server.rate_limit.burst"}},
+		{"check timeout above interval", func(c *Config) {
+			c.Health.CheckInterval = 5
+			c.Health.CheckTimeout = 10
+		}, []string{"health.check_timeout"}},
+		{"database path is a directory", func(c *Config) { c.Database.SQLiteDBPath = os.TempDir() }, []string{"database.sqlite_db_path"}},
+		{"paths", func(c *Config) {
+			c.Gateway.MetricsPath = "metrics"
+			c.Gateway.BasePath = "api"
+		}, []string{"gateway.metrics_path", "gateway.base_path"}},
+		{"trusted proxies", func(c *Config) {
+			c.Gateway.TrustedProxies = []string{"10.0.0.1", "10.0.0.0/8", "proxy.internal"}
+		}, []string{"gateway.trusted_proxies[2]"}},
+		{"listen addresses", func(c *Config) {
+			c.Server.Listen = []string{"tcp://:50051", "unix://run/app/grpc.sock"}
+			c.Gateway.Listen = []string{"unix:///run/app/http.sock?mode=0660", "unix:///run/app/http.sock?mode=0999", "localhost"}
+		}, []string{"server.listen[1]", "gateway.listen[1]", "gateway.listen[2]"}},
+		{"retry backoff", func(c *Config) {
+			c.Gateway.Upstream.Retry.MaxAttempts = 3
+			c.Gateway.Upstream.Retry.InitialBackoffMs = 500
+			c.Gateway.Upstream.Retry.MaxBackoffMs = 100
+		}, []string{"gateway.upstream.retry.max_backoff_ms"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			tt.modify(cfg)
+
+			var keys []string
+			for _, p := range cfg.problems() {
+				keys = append(keys, p.Key)
+			}
+			assert.ElementsMatch(t, tt.keys, keys)
+
+			err := cfg.Validate()
+			if len(tt.keys) == 0 {
+				assert.NoError(t, err)
+			} else {
+				var verr *ValidationError
+				require.ErrorAs(t, err, &verr)
+				assert.Len(t, verr.Problems, len(tt.keys))
+			}
+		})
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		problems []string
+	}{
+		{"known keys", "app:\n  name: svc\nserver:\n  port: 1\n", nil},
+		{"keys ignore case", "App:\n  Name: svc\n", nil},
+		{"free-form map keys", "server:\n  timeouts:\n    /user.UserService/GetUser: 5\n", nil},
+		{"typo", "server:\n  prot: 1\n", []string{"server.prot: unknown setting; did you mean server.port?"}},
+		{"misspelled section", "servre:\n  port: 1\n", []string{"servre: unknown setting; did you mean server?"}},
+		{"truncated name", "server:\n  keepalive:\n    max_connection: 1\n", []string{
+			"server.keepalive.max_connection: unknown setting; did you mean server.keepalive.max_connection_age?",
+		}},
+		{"wrong section", "app:\n  sqlite_db_path: x\n", []string{"app.sqlite_db_path: unknown setting; did you mean database.sqlite_db_path?"}},
+		{"no suggestion", "cache:\n  size: 1\n", []string{"cache: unknown setting"}},
+		{"sorted", "zzz_unused: 1\napp:\n  nmae: svc\n", []string{
+			"app.nmae: unknown setting; did you mean app.name?",
+			"zzz_unused: unknown setting",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.yaml), 0o644))
+
+			problems, err := unknownKeys(path)
+			require.NoError(t, err)
+			var got []string
+			for _, p := range problems {
+				assert.Equal(t, "file "+path, p.Source)
+				got = append(got, p.Key+": "+p.Message)
+			}
+			assert.Equal(t, tt.problems, got)
+		})
+	}
+}
+
+func TestUnknownKeysInvalidYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("app: [unclosed"), 0o644))
+	_, err := unknownKeys(path)
+	assert.Error(t, err)
+}
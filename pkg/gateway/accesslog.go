@@ -0,0 +1,141 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.uber.org/zap"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/metrics"
+)
+
+// unmatchedRoute labels requests that matched no route, keeping metric
+// cardinality bounded
+const unmatchedRoute = "unmatched"
+
+type routeKey struct{}
+
+// route receives the matched route template from inner handlers
+type route struct {
+	pattern string
+}
+
+// RecordRoute is a grpc-gateway middleware that reports the matched path
+// template, such as /v1/users/{id}, to AccessLog. Use it with
+// runtime.WithMiddlewares.
+func RecordRoute(next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		if rt, ok := r.Context().Value(routeKey{}).(*route); ok {
+			if pattern, ok := runtime.HTTPPattern(r.Context()); ok {
+				rt.pattern = strings.ReplaceAll(pattern.String(), "=*}", "}")
+			}
+		}
+		next(w, r, pathParams)
+	}
+}
+
+// AccessLog logs every request and records Prometheus metrics labelled by
+// route template rather than raw path. Logging can be disabled separately
+// from metrics.
+func AccessLog(resolver *ClientIPResolver, logEnabled bool, next http.Handler) http.Handler {
+	log := logger.Named("gateway.access")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		metrics.HTTPActiveRequests.Inc()
+		defer metrics.HTTPActiveRequests.Dec()
+
+		rt := &route{}
+		r = r.WithContext(context.WithValue(r.Context(), routeKey{}, rt))
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		// Routes served by http.ServeMux are recorded on the request
+		pattern := rt.pattern
+		if pattern == "" {
+			pattern = r.Pattern
+		}
+		if pattern == "" {
+			pattern = unmatchedRoute
+		}
+		status := rec.statusCode()
+		duration := time.Since(start)
+
+		metrics.HTTPRequestCounter.WithLabelValues(r.Method, pattern, strconv.Itoa(status)).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(r.Method, pattern).Observe(duration.Seconds())
+		metrics.HTTPResponseSize.WithLabelValues(r.Method, pattern).Observe(float64(rec.bytes))
+
+		if !logEnabled {
+			return
+		}
+		fields := []zap.Field{
+			zap.String("method", r.Method),
+			zap.String("route", pattern),
+			zap.String("path", r.URL.Path),
+			zap.Int("status", status),
+			zap.Int64("bytes", rec.bytes),
+			zap.Duration("duration", duration),
+			zap.Int64("duration_ms", duration.Milliseconds()),
+			zap.String("request_id", r.Header.Get(RequestIDHeader)),
+			zap.String("client_ip", resolver.ClientIP(r)),
+			zap.String("user_agent", r.UserAgent()),
+		}
+		switch {
+		case status >= 500:
+			log.Error("HTTP request", fields...)
+		case status >= 400:
+			log.Warn("HTTP request", fields...)
+		default:
+			log.Info("HTTP request", fields...)
+		}
+	})
+}
+
+// statusRecorder captures the status code and body size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader records the status code
+func (rec *statusRecorder) WriteHeader(code int) {
+	if rec.status == 0 && code >= 200 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Write counts the bytes written
+func (rec *statusRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(p)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher for streaming responses
+func (rec *statusRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (rec *statusRecorder) statusCode() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
@@ -0,0 +1,97 @@
+package gateway
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIPResolver determines the address of the client behind any trusted
+// reverse proxies
+type ClientIPResolver struct {
+	trusted []*net.IPNet
+}
+
+// NewClientIPResolver creates a resolver trusting the given proxies, listed
+// as CIDR ranges or single addresses
+func NewClientIPResolver(trustedProxies []string) (*ClientIPResolver, error) {
+	r := &ClientIPResolver{}
+	for _, p := range trustedProxies {
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", p, err)
+		}
+		r.trusted = append(r.trusted, ipNet)
+	}
+	return r, nil
+}
+
+// ClientIP returns the client address. X-Forwarded-For is only honoured
+// when the connection comes from a trusted proxy; it is then read from the
+// right, skipping trusted proxies, so clients cannot spoof their address.
+func (r *ClientIPResolver) ClientIP(req *http.Request) string {
+	if hops := r.ForwardedFor(req); len(hops) > 0 {
+		return hops[0]
+	}
+	return remoteIP(req)
+}
+
+// FromTrustedProxy reports whether the request's connection comes from a
+// trusted proxy, whose forwarding headers can be believed
+func (r *ClientIPResolver) FromTrustedProxy(req *http.Request) bool {
+	return r != nil && r.isTrusted(remoteIP(req))
+}
+
+// ForwardedFor returns the X-Forwarded-For hops that can be trusted: the
+// client address followed by the trusted proxies in front of the immediate
+// peer. It is empty when the peer is not a trusted proxy.
+func (r *ClientIPResolver) ForwardedFor(req *http.Request) []string {
+	if !r.FromTrustedProxy(req) {
+		return nil
+	}
+
+	var hops []string
+	for _, v := range req.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !r.isTrusted(hops[i]) {
+			return hops[i:]
+		}
+	}
+	return hops
+}
+
+func (r *ClientIPResolver) isTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range r.trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func remoteIP(req *http.Request) string {
+	remote, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return remote
+}
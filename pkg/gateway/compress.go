@@ -0,0 +1,211 @@
+package gateway
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+// Supported content encodings
+const (
+	EncodingBrotli = "br"
+	EncodingGzip   = "gzip"
+)
+
+// Compress compresses responses with brotli or gzip, whichever the client
+// accepts first in the configured order. Bodies smaller than the configured
+// minimum and content types outside the configured list are sent as is.
+func Compress(cfg config.CompressionConfig, next http.Handler) http.Handler {
+	if !cfg.Enabled || len(cfg.Encodings) == 0 {
+		return next
+	}
+
+	types := make(map[string]bool, len(cfg.ContentTypes))
+	for _, t := range cfg.ContentTypes {
+		types[strings.ToLower(t)] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), cfg.Encodings)
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: w,
+			encoding:       encoding,
+			minSize:        cfg.MinSizeBytes,
+			types:          types,
+		}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding returns the first supported encoding the client accepts
+// with a non-zero quality, or "" to send the response uncompressed
+func negotiateEncoding(acceptEncoding string, supported []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := make(map[string]bool)
+	wildcard := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				accepted[name] = false
+				continue
+			}
+		}
+		if name == "*" {
+			wildcard = true
+			continue
+		}
+		accepted[name] = true
+	}
+
+	for _, enc := range supported {
+		enc = strings.ToLower(enc)
+		if enc != EncodingBrotli && enc != EncodingGzip {
+			continue
+		}
+		if ok, listed := accepted[enc]; ok || (!listed && wildcard) {
+			return enc
+		}
+	}
+	return ""
+}
+
+// compressWriter buffers the start of a response until it knows whether the
+// body is large enough to compress, then streams it through the encoder
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+	types    map[string]bool
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder io.WriteCloser
+}
+
+// WriteHeader records the status until the compression decision is made
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.decided || cw.status != 0 {
+		return
+	}
+	if code < 200 {
+		// Informational responses are sent immediately
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	cw.status = code
+}
+
+// Write buffers data until the minimum size is reached
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cw.minSize {
+			return len(p), nil
+		}
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush sends buffered data, which streaming responses rely on
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.decide(len(cw.buf) >= cw.minSize)
+	}
+	if f, ok := cw.encoder.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close completes the response and the compressed stream
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if err := cw.decide(len(cw.buf) >= cw.minSize); err != nil {
+			return err
+		}
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Close()
+	}
+	return nil
+}
+
+// decide writes the headers, choosing whether to compress, and the buffered data
+func (cw *compressWriter) decide(largeEnough bool) error {
+	cw.decided = true
+
+	h := cw.Header()
+	compressible := cw.compressible(h)
+	if compressible {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	if compressible && largeEnough {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+		cw.encoder = newEncoder(cw.encoding, cw.ResponseWriter)
+	}
+
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.encoder != nil {
+		_, err := cw.encoder.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+func (cw *compressWriter) compressible(h http.Header) bool {
+	if cw.status == http.StatusNoContent || cw.status == http.StatusNotModified || h.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(cw.buf)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && cw.types[mediaType]
+}
+
+func newEncoder(encoding string, w io.Writer) io.WriteCloser {
+	if encoding == EncodingBrotli {
+		return brotli.NewWriter(w)
+	}
+	return gzip.NewWriter(w)
+}
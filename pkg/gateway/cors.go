@@ -0,0 +1,169 @@
+package gateway
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+// CORSPolicy holds CORS settings that can be updated while the gateway is
+// running
+type CORSPolicy struct {
+	state atomic.Pointer[cors]
+}
+
+// NewCORSPolicy creates a CORS policy from the gateway configuration
+func NewCORSPolicy(cfg config.CORSConfig) *CORSPolicy {
+	p := &CORSPolicy{}
+	p.Update(cfg)
+	return p
+}
+
+// Update replaces the CORS settings. Clearing the allowed origins disables
+// CORS.
+func (p *CORSPolicy) Update(cfg config.CORSConfig) {
+	c := &cors{
+		cfg:            cfg,
+		allowedMethods: strings.Join(upper(cfg.AllowedMethods), ", "),
+		allowedHeaders: make(map[string]bool, len(cfg.AllowedHeaders)),
+		exposedHeaders: strings.Join(cfg.ExposedHeaders, ", "),
+	}
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			c.anyOrigin = true
+		}
+	}
+	for _, h := range cfg.AllowedHeaders {
+		if h == "*" {
+			c.anyHeader = true
+		}
+		c.allowedHeaders[http.CanonicalHeaderKey(h)] = true
+	}
+	p.state.Store(c)
+}
+
+// Handler answers preflight requests and adds CORS headers for origins
+// allowed by the current settings
+func (p *CORSPolicy) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := p.state.Load()
+		origin := r.Header.Get("Origin")
+		if origin == "" || len(c.cfg.AllowedOrigins) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			c.preflight(w, r, origin)
+			return
+		}
+
+		if c.originAllowed(origin) {
+			c.setOrigin(w, origin)
+			if c.exposedHeaders != "" {
+				w.Header().Set("Access-Control-Expose-Headers", c.exposedHeaders)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type cors struct {
+	cfg            config.CORSConfig
+	anyOrigin      bool
+	anyHeader      bool
+	allowedMethods string
+	allowedHeaders map[string]bool
+	exposedHeaders string
+}
+
+// preflight answers an OPTIONS preflight request. Disallowed requests get
+// no CORS headers, which makes the browser reject them.
+func (c *cors) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	requested := r.Header.Get("Access-Control-Request-Headers")
+	if !c.originAllowed(origin) ||
+		!c.methodAllowed(r.Header.Get("Access-Control-Request-Method")) ||
+		!c.headersAllowed(requested) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	c.setOrigin(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", c.allowedMethods)
+	if requested != "" {
+		w.Header().Set("Access-Control-Allow-Headers", requested)
+	}
+	if c.cfg.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(c.cfg.MaxAge))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setOrigin sets the allowed origin, echoing it rather than "*" when
+// credentials are allowed, since browsers reject "*" with credentials
+func (c *cors) setOrigin(w http.ResponseWriter, origin string) {
+	if c.anyOrigin && !c.cfg.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.cfg.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *cors) originAllowed(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	for _, allowed := range c.cfg.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == origin {
+			return true
+		}
+		// https://*.example.com matches any subdomain of example.com
+		if prefix, suffix, ok := strings.Cut(allowed, "*"); ok &&
+			len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cors) methodAllowed(method string) bool {
+	for _, m := range c.cfg.AllowedMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cors) headersAllowed(requested string) bool {
+	if c.anyHeader {
+		return true
+	}
+	for _, h := range strings.Split(requested, ",") {
+		if h = strings.TrimSpace(h); h != "" && !c.allowedHeaders[http.CanonicalHeaderKey(h)] {
+			return false
+		}
+	}
+	return true
+}
+
+func upper(values []string) []string {
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = strings.ToUpper(v)
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+package gateway
+
+import (
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+// Headers that grpc-gateway or the gateway forward on their own, which
+// matchers skip so they don't reach the server twice
+var forwardedSeparately = map[string]bool{
+	"Authorization":    true,
+	"X-Forwarded-For":  true,
+	"X-Forwarded-Host": true,
+	RequestIDHeader:    true,
+}
+
+// ServeMuxOptions returns the runtime.ServeMux options shared by the
+// standalone gateway and the single-port server: problem error handlers,
+// route recording, request ID forwarding, the configured header mapping and
+// X-Forwarded-For restricted to trusted proxies
+func ServeMuxOptions(cfg config.GatewayConfig) ([]runtime.ServeMuxOption, error) {
+	resolver, err := NewClientIPResolver(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	incoming := newHeaderPatterns(cfg.Headers.Incoming)
+	outgoing := newHeaderPatterns(cfg.Headers.Outgoing)
+
+	return []runtime.ServeMuxOption{
+		runtime.WithErrorHandler(ErrorHandler),
+		runtime.WithRoutingErrorHandler(RoutingErrorHandler),
+		runtime.WithMiddlewares(RecordRoute, trustedForwardedFor(resolver)),
+		runtime.WithMetadata(RequestIDMetadata),
+		runtime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+			key = textproto.CanonicalMIMEHeaderKey(key)
+			if forwardedSeparately[key] {
+				return "", false
+			}
+			if incoming.match(key) {
+				return strings.ToLower(key), true
+			}
+			return runtime.DefaultHeaderMatcher(key)
+		}),
+		runtime.WithOutgoingHeaderMatcher(func(key string) (string, bool) {
+			if outgoing.match(key) {
+				return key, true
+			}
+			return runtime.MetadataHeaderPrefix + key, true
+		}),
+		runtime.WithOutgoingTrailerMatcher(func(key string) (string, bool) {
+			if outgoing.match(key) {
+				return key, true
+			}
+			return runtime.MetadataTrailerPrefix + key, true
+		}),
+	}, nil
+}
+
+// trustedForwardedFor replaces X-Forwarded-For with the hops added by
+// trusted proxies before grpc-gateway forwards it as x-forwarded-for
+// metadata, so clients cannot spoof the address the server sees.
+// grpc-gateway appends the connection's remote address itself.
+func trustedForwardedFor(resolver *ClientIPResolver) runtime.Middleware {
+	return func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+			hops := resolver.ForwardedFor(r)
+			r = r.WithContext(r.Context())
+			r.Header = r.Header.Clone()
+			r.Header.Del("X-Forwarded-For")
+			if len(hops) > 0 {
+				r.Header.Set("X-Forwarded-For", strings.Join(hops, ", "))
+			}
+			next(w, r, pathParams)
+		}
+	}
+}
+
+// headerPatterns matches header names case-insensitively; a trailing *
+// matches any suffix
+type headerPatterns struct {
+	exact    map[string]bool
+	prefixes []string
+}
+
+func newHeaderPatterns(patterns []string) headerPatterns {
+	p := headerPatterns{exact: make(map[string]bool, len(patterns))}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			p.prefixes = append(p.prefixes, prefix)
+		} else if pattern != "" {
+			p.exact[pattern] = true
+		}
+	}
+	return p
+}
+
+func (p headerPatterns) match(key string) bool {
+	key = strings.ToLower(key)
+	if p.exact[key] {
+		return true
+	}
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
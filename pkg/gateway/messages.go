@@ -0,0 +1,122 @@
+package gateway
+
+import (
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+)
+
+// supportedLanguages lists the languages problem titles are translated to.
+// The first entry is the fallback.
+var supportedLanguages = []language.Tag{
+	language.English,
+	language.Spanish,
+	language.German,
+	language.French,
+}
+
+var languageMatcher = language.NewMatcher(supportedLanguages)
+
+// matchLanguage returns the supported language that best matches an
+// Accept-Language header or locale
+func matchLanguage(acceptLanguage string) language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return supportedLanguages[0]
+	}
+	_, index, _ := languageMatcher.Match(tags...)
+	return supportedLanguages[index]
+}
+
+// sameLanguage reports whether a locale is in the given language
+func sameLanguage(locale string, lang language.Tag) bool {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return false
+	}
+	base, _ := tag.Base()
+	langBase, _ := lang.Base()
+	return base == langBase
+}
+
+// title returns the localised title of a gRPC code
+func title(lang language.Tag, code codes.Code) string {
+	if t, ok := titles[lang][code]; ok {
+		return t
+	}
+	return titles[language.English][code]
+}
+
+var titles = map[language.Tag]map[codes.Code]string{
+	language.English: {
+		codes.Canceled:           "Request cancelled",
+		codes.Unknown:            "Unknown error",
+		codes.InvalidArgument:    "Invalid argument",
+		codes.DeadlineExceeded:   "Deadline exceeded",
+		codes.NotFound:           "Not found",
+		codes.AlreadyExists:      "Already exists",
+		codes.PermissionDenied:   "Permission denied",
+		codes.ResourceExhausted:  "Too many requests",
+		codes.FailedPrecondition: "Failed precondition",
+		codes.Aborted:            "Conflict",
+		codes.OutOfRange:         "Out of range",
+		codes.Unimplemented:      "Not implemented",
+		codes.Internal:           "Internal error",
+		codes.Unavailable:        "Service unavailable",
+		codes.DataLoss:           "Data loss",
+		codes.Unauthenticated:    "Unauthenticated",
+	},
+	language.Spanish: {
+		codes.Canceled:           "Solicitud cancelada",
+		codes.Unknown:            "Error desconocido",
+		codes.InvalidArgument:    "Argumento no válido",
+		codes.DeadlineExceeded:   "Tiempo de espera agotado",
+		codes.NotFound:           "No encontrado",
+		codes.AlreadyExists:      "Ya existe",
+		codes.PermissionDenied:   "Permiso denegado",
+		codes.ResourceExhausted:  "Demasiadas solicitudes",
+		codes.FailedPrecondition: "Condición previa no cumplida",
+		codes.Aborted:            "Conflicto",
+		codes.OutOfRange:         "Fuera de rango",
+		codes.Unimplemented:      "No implementado",
+		codes.Internal:           "Error interno",
+		codes.Unavailable:        "Servicio no disponible",
+		codes.DataLoss:           "Pérdida de datos",
+		codes.Unauthenticated:    "No autenticado",
+	},
+	language.German: {
+		codes.Canceled:           "Anfrage abgebrochen",
+		codes.Unknown:            "Unbekannter Fehler",
+		codes.InvalidArgument:    "Ungültiges Argument",
+		codes.DeadlineExceeded:   "Zeitlimit überschritten",
+		codes.NotFound:           "Nicht gefunden",
+		codes.AlreadyExists:      "Bereits vorhanden",
+		codes.PermissionDenied:   "Zugriff verweigert",
+		codes.ResourceExhausted:  "Zu viele Anfragen",
+		codes.FailedPrecondition: "Vorbedingung nicht erfüllt",
+		codes.Aborted:            "Konflikt",
+		codes.OutOfRange:         "Außerhalb des gültigen Bereichs",
+		codes.Unimplemented:      "Nicht implementiert",
+		codes.Internal:           "Interner Fehler",
+		codes.Unavailable:        "Dienst nicht verfügbar",
+		codes.DataLoss:           "Datenverlust",
+		codes.Unauthenticated:    "Nicht authentifiziert",
+	},
+	language.French: {
+		codes.Canceled:           "Requête annulée",
+		codes.Unknown:            "Erreur inconnue",
+		codes.InvalidArgument:    "Argument non valide",
+		codes.DeadlineExceeded:   "Délai dépassé",
+		codes.NotFound:           "Introuvable",
+		codes.AlreadyExists:      "Existe déjà",
+		codes.PermissionDenied:   "Permission refusée",
+		codes.ResourceExhausted:  "Trop de requêtes",
+		codes.FailedPrecondition: "Précondition non remplie",
+		codes.Aborted:            "Conflit",
+		codes.OutOfRange:         "Hors limites",
+		codes.Unimplemented:      "Non implémenté",
+		codes.Internal:           "Erreur interne",
+		codes.Unavailable:        "Service indisponible",
+		codes.DataLoss:           "Perte de données",
+		codes.Unauthenticated:    "Non authentifié",
+	},
+}
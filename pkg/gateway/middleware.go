@@ -0,0 +1,23 @@
+package gateway
+
+import (
+	"net/http"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+// Middleware wraps the gateway's HTTP handler with request IDs, access
+// logging and metrics, CORS, security headers and response compression as
+// configured. CORS follows the given policy, which may be updated later.
+func Middleware(cfg config.GatewayConfig, cors *CORSPolicy, next http.Handler) (http.Handler, error) {
+	resolver, err := NewClientIPResolver(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := Compress(cfg.Compression, next)
+	handler = SecurityHeaders(cfg.SecurityHeaders, handler)
+	handler = cors.Handler(handler)
+	handler = AccessLog(resolver, cfg.AccessLog, handler)
+	return RequestID(handler), nil
+}
@@ -0,0 +1,168 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem documents
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeBase prefixes problem type URIs; the fragment is the gRPC code
+// in kebab case, documented in docs/problems.md
+const ProblemTypeBase = "https://github.com/Akashdeep-Patra/go-grpc-sqlite/blob/main/docs/problems.md#"
+
+// Problem is an RFC 7807 problem document. Its schema is documented as the
+// Problem message in api/user.proto.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	Code          string         `json:"code"`
+	RequestID     string         `json:"request_id,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
+
+// InvalidParam describes a request field that failed validation
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ErrorHandler writes gRPC errors as RFC 7807 problem documents. It keeps
+// the behaviour of runtime.DefaultHTTPErrorHandler for status codes,
+// forwarded metadata and trailers, replacing only the body.
+func ErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	pm := &problemMarshaler{Marshaler: marshaler, r: r}
+	var httpErr *runtime.HTTPStatusError
+	if errors.As(err, &httpErr) {
+		pm.httpStatus = httpErr.HTTPStatus
+	}
+	runtime.DefaultHTTPErrorHandler(ctx, mux, pm, w, r, err)
+}
+
+// RoutingErrorHandler reports requests that match no route as problems,
+// keeping the original HTTP status such as 405 Method Not Allowed rather
+// than the status mapped from the gRPC code
+func RoutingErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, httpStatus int) {
+	code := codes.Internal
+	switch httpStatus {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusMethodNotAllowed:
+		code = codes.Unimplemented
+	case http.StatusNotFound:
+		code = codes.NotFound
+	}
+	err := &runtime.HTTPStatusError{
+		HTTPStatus: httpStatus,
+		Err:        status.Error(code, http.StatusText(httpStatus)),
+	}
+	ErrorHandler(ctx, mux, marshaler, w, r, err)
+}
+
+// NewProblem builds the problem document for a gRPC status, using the HTTP
+// status mapped from its code when httpStatus is zero. Titles and, when the
+// status carries a matching LocalizedMessage, the detail are localised for
+// the request's Accept-Language.
+func NewProblem(r *http.Request, st *status.Status, httpStatus int) *Problem {
+	lang := matchLanguage(r.Header.Get("Accept-Language"))
+	if httpStatus == 0 {
+		httpStatus = runtime.HTTPStatusFromCode(st.Code())
+	}
+
+	p := &Problem{
+		Type:      ProblemTypeBase + problemSlug(st.Code()),
+		Title:     title(lang, st.Code()),
+		Status:    httpStatus,
+		Detail:    st.Message(),
+		Instance:  r.URL.Path,
+		Code:      codeName(st.Code()),
+		RequestID: r.Header.Get(RequestIDHeader),
+	}
+
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				p.InvalidParams = append(p.InvalidParams, InvalidParam{Name: v.GetField(), Reason: v.GetDescription()})
+			}
+		case *errdetails.LocalizedMessage:
+			if sameLanguage(d.GetLocale(), lang) && d.GetMessage() != "" {
+				p.Detail = d.GetMessage()
+			}
+		}
+	}
+
+	return p
+}
+
+// problemMarshaler renders the status written by the default error handler
+// as a problem document
+type problemMarshaler struct {
+	runtime.Marshaler
+	r          *http.Request
+	httpStatus int
+}
+
+// ContentType implements runtime.Marshaler
+func (m *problemMarshaler) ContentType(v interface{}) string {
+	if _, ok := v.(*spb.Status); ok {
+		return ProblemContentType
+	}
+	return m.Marshaler.ContentType(v)
+}
+
+// Marshal implements runtime.Marshaler
+func (m *problemMarshaler) Marshal(v interface{}) ([]byte, error) {
+	s, ok := v.(*spb.Status)
+	if !ok {
+		return m.Marshaler.Marshal(v)
+	}
+	return json.Marshal(NewProblem(m.r, status.FromProto(s), m.httpStatus))
+}
+
+// codeName returns the canonical upper snake case name of a gRPC code
+func codeName(code codes.Code) string {
+	name, ok := codeNames[code]
+	if !ok {
+		return codeNames[codes.Unknown]
+	}
+	return name
+}
+
+// problemSlug returns the problem type fragment for a gRPC code
+func problemSlug(code codes.Code) string {
+	return strings.ReplaceAll(strings.ToLower(codeName(code)), "_", "-")
+}
+
+var codeNames = map[codes.Code]string{
+	codes.OK:                 "OK",
+	codes.Canceled:           "CANCELLED",
+	codes.Unknown:            "UNKNOWN",
+	codes.InvalidArgument:    "INVALID_ARGUMENT",
+	codes.DeadlineExceeded:   "DEADLINE_EXCEEDED",
+	codes.NotFound:           "NOT_FOUND",
+	codes.AlreadyExists:      "ALREADY_EXISTS",
+	codes.PermissionDenied:   "PERMISSION_DENIED",
+	codes.ResourceExhausted:  "RESOURCE_EXHAUSTED",
+	codes.FailedPrecondition: "FAILED_PRECONDITION",
+	codes.Aborted:            "ABORTED",
+	codes.OutOfRange:         "OUT_OF_RANGE",
+	codes.Unimplemented:      "UNIMPLEMENTED",
+	codes.Internal:           "INTERNAL",
+	codes.Unavailable:        "UNAVAILABLE",
+	codes.DataLoss:           "DATA_LOSS",
+	codes.Unauthenticated:    "UNAUTHENTICATED",
+}
@@ -0,0 +1,36 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDHeader carries the request ID between clients, the gateway and
+// the gRPC server
+const RequestIDHeader = "X-Request-Id"
+
+// RequestID assigns a request ID to requests that lack one and echoes it in
+// the response, so REST clients can quote it when reporting problems
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = uuid.NewString()
+			r.Header.Set(RequestIDHeader, id)
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequestIDMetadata forwards the request ID to the gRPC server as
+// x-request-id metadata. Use it with runtime.WithMetadata.
+func RequestIDMetadata(_ context.Context, r *http.Request) metadata.MD {
+	if id := r.Header.Get(RequestIDHeader); id != "" {
+		return metadata.Pairs("x-request-id", id)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	contentTypeGRPC    = "application/grpc"
+	contentTypeWeb     = "application/grpc-web"
+	contentTypeWebText = "application/grpc-web-text"
+
+	// trailerFlag marks the frame carrying the trailers at the end of a response
+	trailerFlag = 0x80
+)
+
+// IsGRPCWebRequest reports whether r is a gRPC-Web request
+func IsGRPCWebRequest(r *http.Request) bool {
+	return r.Method == http.MethodPost && strings.HasPrefix(r.Header.Get("Content-Type"), contentTypeWeb)
+}
+
+// Handler translates gRPC-Web requests into native gRPC requests for the
+// given handler, usually a *grpc.Server. Requests that are not gRPC-Web are
+// passed through unchanged.
+func Handler(grpcHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsGRPCWebRequest(r) {
+			grpcHandler.ServeHTTP(w, r)
+			return
+		}
+
+		contentType := r.Header.Get("Content-Type")
+		text := strings.HasPrefix(contentType, contentTypeWebText)
+
+		// grpc.Server.ServeHTTP only accepts HTTP/2 requests with a gRPC content type
+		req := r.Clone(r.Context())
+		req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+		req.Header.Set("Content-Type", nativeContentType(contentType))
+		req.Header.Del("Content-Length")
+		req.ContentLength = -1
+		if text {
+			req.Body = io.NopCloser(base64.NewDecoder(base64.StdEncoding, r.Body))
+		}
+
+		rw := &responseWriter{
+			w:           w,
+			header:      make(http.Header),
+			contentType: contentType,
+			text:        text,
+		}
+		grpcHandler.ServeHTTP(rw, req)
+		rw.finish()
+	})
+}
+
+// nativeContentType maps a gRPC-Web content type to its gRPC equivalent,
+// keeping the message codec suffix such as "+proto"
+func nativeContentType(contentType string) string {
+	codec := strings.TrimPrefix(contentType, contentTypeWebText)
+	if codec == contentType {
+		codec = strings.TrimPrefix(contentType, contentTypeWeb)
+	}
+	if i := strings.IndexByte(codec, ';'); i >= 0 {
+		codec = codec[:i]
+	}
+	return contentTypeGRPC + codec
+}
+
+// responseWriter turns a native gRPC response into a gRPC-Web one: HTTP
+// trailers are moved into a length-prefixed frame at the end of the body,
+// which is base64 encoded for the text format.
+type responseWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	contentType string
+	text        bool
+	wroteHeader bool
+}
+
+// Header returns the headers and trailers set by the gRPC server
+func (rw *responseWriter) Header() http.Header {
+	return rw.header
+}
+
+// WriteHeader sends the response headers, leaving out trailers
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+
+	trailers := rw.trailerKeys()
+	h := rw.w.Header()
+	for k, v := range rw.header {
+		if k == "Trailer" || trailers[k] || strings.HasPrefix(k, http.TrailerPrefix) {
+			continue
+		}
+		h[k] = v
+	}
+	h.Set("Content-Type", rw.contentType)
+	h.Del("Content-Length")
+	rw.w.WriteHeader(code)
+}
+
+// Write writes message frames to the client
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if !rw.text {
+		return rw.w.Write(p)
+	}
+	if _, err := rw.w.Write([]byte(base64.StdEncoding.EncodeToString(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush implements http.Flusher, which grpc.Server.ServeHTTP requires
+func (rw *responseWriter) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes the trailer frame once the gRPC server is done
+func (rw *responseWriter) finish() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	trailers := make(map[string][]string)
+	for k := range rw.trailerKeys() {
+		if v, ok := rw.header[k]; ok {
+			trailers[strings.ToLower(k)] = v
+		}
+	}
+	for k, v := range rw.header {
+		if strings.HasPrefix(k, http.TrailerPrefix) {
+			trailers[strings.ToLower(strings.TrimPrefix(k, http.TrailerPrefix))] = v
+		}
+	}
+
+	keys := make([]string, 0, len(trailers))
+	for k := range trailers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var body bytes.Buffer
+	for _, k := range keys {
+		for _, v := range trailers[k] {
+			body.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+
+	frame := make([]byte, 5, 5+body.Len())
+	frame[0] = trailerFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(body.Len()))
+	frame = append(frame, body.Bytes()...)
+
+	rw.Write(frame)
+	rw.Flush()
+}
+
+// trailerKeys returns the canonical names announced in the Trailer header
+func (rw *responseWriter) trailerKeys() map[string]bool {
+	keys := make(map[string]bool)
+	for _, v := range rw.header["Trailer"] {
+		for _, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				keys[http.CanonicalHeaderKey(k)] = true
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,40 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// DiskSpace returns a checker that fails when the filesystem holding path
+// has less than minFreeBytes available
+func DiskSpace(name, path string, minFreeBytes uint64) Checker {
+	return CheckFunc(name, func(ctx context.Context) error {
+		free, err := freeBytes(path)
+		if err != nil {
+			return fmt.Errorf("unable to stat %s: %w", path, err)
+		}
+		if free < minFreeBytes {
+			return fmt.Errorf("%d bytes free on %s, need at least %d", free, path, minFreeBytes)
+		}
+		return nil
+	})
+}
+
+// GRPCHealth returns a checker that calls the standard gRPC health Check RPC
+// on an upstream connection and fails unless it reports SERVING
+func GRPCHealth(name string, conn grpc.ClientConnInterface, service string) Checker {
+	client := grpc_health_v1.NewHealthClient(conn)
+	return CheckFunc(name, func(ctx context.Context) error {
+		resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+		if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			return fmt.Errorf("upstream status is %s", resp.Status)
+		}
+		return nil
+	})
+}
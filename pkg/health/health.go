@@ -0,0 +1,220 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
+)
+
+// Probe distinguishes liveness checks (restart the process when failing)
+// from readiness checks (stop routing traffic when failing)
+type Probe string
+
+const (
+	// Liveness reports whether the process is able to make progress
+	Liveness Probe = "liveness"
+	// Readiness reports whether the process can serve requests
+	Readiness Probe = "readiness"
+)
+
+// Checker probes a single dependency
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// checkFunc adapts a function to the Checker interface
+type checkFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (c *checkFunc) Name() string                    { return c.name }
+func (c *checkFunc) Check(ctx context.Context) error { return c.fn(ctx) }
+
+// CheckFunc returns a Checker with the given name that calls fn
+func CheckFunc(name string, fn func(ctx context.Context) error) Checker {
+	return &checkFunc{name: name, fn: fn}
+}
+
+// Result is the outcome of the last run of one checker
+type Result struct {
+	Name      string    `json:"name"`
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	Duration  float64   `json:"duration_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Registry runs registered checkers on an interval and reports the aggregate
+// liveness and readiness of the process
+type Registry struct {
+	interval time.Duration
+	timeout  time.Duration
+
+	mu        sync.RWMutex
+	checkers  map[Probe][]Checker
+	results   map[Probe][]Result
+	healthy   map[Probe]bool
+	evaluated map[Probe]bool
+	listeners []func(probe Probe, healthy bool)
+	// shuttingDown keeps readiness failing once shutdown has begun
+	shuttingDown bool
+}
+
+// NewRegistry creates a registry that runs checks every interval, giving
+// each checker at most timeout to complete
+func NewRegistry(interval, timeout time.Duration) *Registry {
+	return &Registry{
+		interval:  interval,
+		timeout:   timeout,
+		checkers:  make(map[Probe][]Checker),
+		results:   make(map[Probe][]Result),
+		healthy:   make(map[Probe]bool),
+		evaluated: make(map[Probe]bool),
+	}
+}
+
+// Register adds a checker to a probe
+func (r *Registry) Register(probe Probe, c Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkers[probe] = append(r.checkers[probe], c)
+}
+
+// OnChange registers a listener called whenever a probe flips between
+// healthy and unhealthy, and once for each probe after the first run
+func (r *Registry) OnChange(fn func(probe Probe, healthy bool)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+// Start runs all checks once synchronously, then keeps running them on the
+// registry interval until ctx is cancelled
+func (r *Registry) Start(ctx context.Context) {
+	r.RunOnce(ctx)
+
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.RunOnce(ctx)
+			}
+		}
+	}()
+}
+
+// RunOnce runs every checker and notifies listeners of status changes
+func (r *Registry) RunOnce(ctx context.Context) {
+	for _, probe := range []Probe{Liveness, Readiness} {
+		r.mu.RLock()
+		checkers := append([]Checker(nil), r.checkers[probe]...)
+		r.mu.RUnlock()
+
+		results := r.run(ctx, checkers)
+		healthy := true
+		for _, res := range results {
+			healthy = healthy && res.Healthy
+		}
+
+		r.mu.Lock()
+		if probe == Readiness && r.shuttingDown {
+			healthy = false
+		}
+		changed := !r.evaluated[probe] || r.healthy[probe] != healthy
+		r.results[probe] = results
+		r.healthy[probe] = healthy
+		r.evaluated[probe] = true
+		listeners := append([]func(probe Probe, healthy bool){}, r.listeners...)
+		r.mu.Unlock()
+
+		if !changed {
+			continue
+		}
+		logger.Info("Health probe changed",
+			zap.String("probe", string(probe)),
+			zap.Bool("healthy", healthy),
+			zap.Any("results", results),
+		)
+		for _, fn := range listeners {
+			fn(probe, healthy)
+		}
+	}
+}
+
+// Shutdown marks the process as not ready, regardless of check results, so
+// load balancers stop routing traffic to it while it drains
+func (r *Registry) Shutdown() {
+	r.mu.Lock()
+	if r.shuttingDown {
+		r.mu.Unlock()
+		return
+	}
+	r.shuttingDown = true
+	changed := r.healthy[Readiness]
+	r.healthy[Readiness] = false
+	r.evaluated[Readiness] = true
+	listeners := append([]func(probe Probe, healthy bool){}, r.listeners...)
+	r.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	logger.Info("Health probe changed",
+		zap.String("probe", string(Readiness)),
+		zap.Bool("healthy", false),
+		zap.String("reason", "shutting down"),
+	)
+	for _, fn := range listeners {
+		fn(Readiness, false)
+	}
+}
+
+// run executes checkers concurrently, each bounded by the registry timeout
+func (r *Registry) run(ctx context.Context, checkers []Checker) []Result {
+	results := make([]Result, len(checkers))
+
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Add(1)
+		go func(i int, c Checker) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := c.Check(checkCtx)
+			results[i] = Result{
+				Name:      c.Name(),
+				Healthy:   err == nil,
+				Duration:  float64(time.Since(start).Microseconds()) / 1000,
+				CheckedAt: start,
+			}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// Status returns whether a probe is healthy along with its latest results.
+// A probe that has not run yet is reported unhealthy.
+func (r *Registry) Status(probe Probe) (bool, []Result) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.evaluated[probe] && r.healthy[probe], append([]Result(nil), r.results[probe]...)
+}
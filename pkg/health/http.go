@@ -0,0 +1,40 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
+)
+
+// Handler returns an HTTP handler for Kubernetes-style probes. It responds
+// 200 when the probe is healthy and 503 otherwise, with the individual check
+// results as JSON.
+func (r *Registry) Handler(probe Probe) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		healthy, results := r.Status(probe)
+
+		body := struct {
+			Status string   `json:"status"`
+			Checks []Result `json:"checks"`
+		}{
+			Status: "ok",
+			Checks: results,
+		}
+
+		code := http.StatusOK
+		if !healthy {
+			body.Status = "unavailable"
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			logger.Error("Failed to write response", zap.Error(err))
+		}
+	})
+}
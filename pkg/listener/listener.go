@@ -0,0 +1,125 @@
+// Package listener opens the TCP and Unix domain socket listeners the
+// server and gateway serve on
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Address is a parsed listen address
+type Address struct {
+	// Network is "tcp" or "unix"
+	Network string
+	// Address is host:port for TCP and the socket path for Unix sockets
+	Address string
+	// Mode is the file mode of a Unix socket; 0 keeps the default
+	Mode os.FileMode
+}
+
+// Parse parses a listen address: tcp://host:port, unix:///path/to.sock with
+// an absolute path and an optional ?mode=0660, or a bare host:port for TCP
+func Parse(address string) (Address, error) {
+	scheme, rest, ok := strings.Cut(address, "://")
+	if !ok {
+		scheme, rest = "tcp", address
+	}
+
+	switch scheme {
+	case "tcp":
+		if _, _, err := net.SplitHostPort(rest); err != nil {
+			return Address{}, fmt.Errorf("invalid listen address %q: %w", address, err)
+		}
+		return Address{Network: "tcp", Address: rest}, nil
+	case "unix":
+		path, query, _ := strings.Cut(rest, "?")
+		if path == "" {
+			return Address{}, fmt.Errorf("invalid listen address %q: missing socket path", address)
+		}
+		// unix://run/app.sock would otherwise be a path relative to the
+		// working directory rather than /run/app.sock
+		if !filepath.IsAbs(path) {
+			return Address{}, fmt.Errorf("invalid listen address %q: socket path must be absolute, as in unix:///path/to.sock", address)
+		}
+		a := Address{Network: "unix", Address: path}
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return Address{}, fmt.Errorf("invalid listen address %q: %w", address, err)
+		}
+		for key := range values {
+			if key != "mode" {
+				return Address{}, fmt.Errorf("invalid listen address %q: unknown option %q", address, key)
+			}
+		}
+		if mode := values.Get("mode"); mode != "" {
+			m, err := strconv.ParseUint(mode, 8, 32)
+			if err != nil || m > 0o777 {
+				return Address{}, fmt.Errorf("invalid listen address %q: mode must be octal permissions such as 0660", address)
+			}
+			a.Mode = os.FileMode(m)
+		}
+		return a, nil
+	default:
+		return Address{}, fmt.Errorf("invalid listen address %q: scheme must be tcp or unix", address)
+	}
+}
+
+// Listen opens a listener on a listen address in the form Parse accepts.
+// A stale Unix socket left behind by a process that did not exit cleanly
+// is removed first; a socket another process still serves on is an error.
+// Unix sockets are removed when the listener is closed.
+func Listen(address string) (net.Listener, error) {
+	a, err := Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	if a.Network == "tcp" {
+		return net.Listen("tcp", a.Address)
+	}
+
+	if err := removeStaleSocket(a.Address); err != nil {
+		return nil, err
+	}
+	if a.Mode != 0 {
+		return listenUnix(a.Address, a.Mode)
+	}
+	return net.Listen("unix", a.Address)
+}
+
+// removeStaleSocket removes a socket file nobody accepts connections on
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another process", path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("unable to check socket %s: %w", path, err)
+	}
+	return os.Remove(path)
+}
+
+// URL returns the address lis listens on in the form Parse accepts
+func URL(lis net.Listener) string {
+	addr := lis.Addr()
+	return addr.Network() + "://" + addr.String()
+}
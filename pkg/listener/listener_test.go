@@ -0,0 +1,135 @@
+package listener
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		address string
+		want    Address
+		wantErr string
+	}{
+		{"localhost:50051", Address{Network: "tcp", Address: "localhost:50051"}, ""},
+		{":8080", Address{Network: "tcp", Address: ":8080"}, ""},
+		{"tcp://0.0.0.0:50051", Address{Network: "tcp", Address: "0.0.0.0:50051"}, ""},
+		{"tcp://[::1]:50051", Address{Network: "tcp", Address: "[::1]:50051"}, ""},
+		{"unix:///run/app/grpc.sock", Address{Network: "unix", Address: "/run/app/grpc.sock"}, ""},
+		{"unix:///run/app/grpc.sock?mode=0660", Address{Network: "unix", Address: "/run/app/grpc.sock", Mode: 0o660}, ""},
+		{"unix:///run/app/grpc.sock?mode=600", Address{Network: "unix", Address: "/run/app/grpc.sock", Mode: 0o600}, ""},
+		{"tcp://localhost", Address{}, "missing port"},
+		{"localhost", Address{}, "missing port"},
+		{"udp://:53", Address{}, "scheme must be tcp or unix"},
+		{"unix://", Address{}, "missing socket path"},
+		{"unix://?mode=0660", Address{}, "missing socket path"},
+		{"unix://run/app/grpc.sock", Address{}, "must be absolute"},
+		{"unix://./grpc.sock", Address{}, "must be absolute"},
+		{"unix:///run/app/grpc.sock?mode=0999", Address{}, "octal permissions"},
+		{"unix:///run/app/grpc.sock?mode=01777", Address{}, "octal permissions"},
+		{"unix:///run/app/grpc.sock?mode=rw", Address{}, "octal permissions"},
+		{"unix:///run/app/grpc.sock?owner=app", Address{}, `unknown option "owner"`},
+		{"unix:///run/app/grpc.sock?mode=%zz", Address{}, "invalid URL escape"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			got, err := Parse(tt.address)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestListenUnix(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup prepares the socket path and returns a cleanup, if any
+		setup   func(t *testing.T, path string) func()
+		query   string
+		mode    os.FileMode
+		wantErr string
+	}{
+		{"new socket", nil, "", 0, ""},
+		{"restrictive mode", nil, "?mode=0600", 0o600, ""},
+		{"mode wider than the umask", nil, "?mode=0666", 0o666, ""},
+		{"stale socket is replaced", func(t *testing.T, path string) func() {
+			lis, err := net.Listen("unix", path)
+			require.NoError(t, err)
+			// Leave the file behind as a crashed process would
+			lis.(*net.UnixListener).SetUnlinkOnClose(false)
+			require.NoError(t, lis.Close())
+			return nil
+		}, "", 0, ""},
+		{"socket in use", func(t *testing.T, path string) func() {
+			lis, err := net.Listen("unix", path)
+			require.NoError(t, err)
+			return func() { lis.Close() }
+		}, "", 0, "in use by another process"},
+		{"regular file", func(t *testing.T, path string) func() {
+			require.NoError(t, os.WriteFile(path, []byte("data"), 0o644))
+			return nil
+		}, "", 0, "is not a socket"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.sock")
+			if tt.setup != nil {
+				if cleanup := tt.setup(t, path); cleanup != nil {
+					defer cleanup()
+				}
+			}
+			before, _ := os.Lstat(path)
+
+			lis, err := Listen("unix://" + path + tt.query)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				after, err := os.Lstat(path)
+				require.NoError(t, err, "existing file removed")
+				assert.True(t, os.SameFile(before, after), "existing file replaced")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "unix://"+path, URL(lis))
+
+			info, err := os.Lstat(path)
+			require.NoError(t, err)
+			assert.NotZero(t, info.Mode()&os.ModeSocket)
+			if tt.mode != 0 {
+				assert.Equal(t, tt.mode, info.Mode().Perm())
+			}
+			conn, err := net.Dial("unix", path)
+			require.NoError(t, err)
+			conn.Close()
+
+			require.NoError(t, lis.Close())
+			_, err = os.Lstat(path)
+			assert.ErrorIs(t, err, os.ErrNotExist, "socket left behind on close")
+		})
+	}
+}
+
+func TestListenUnixRestoresUmask(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "before"), nil, 0o666))
+	lis, err := Listen("unix://" + filepath.Join(dir, "app.sock") + "?mode=0600")
+	require.NoError(t, err)
+	defer lis.Close()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "after"), nil, 0o666))
+
+	before, err := os.Stat(filepath.Join(dir, "before"))
+	require.NoError(t, err)
+	after, err := os.Stat(filepath.Join(dir, "after"))
+	require.NoError(t, err)
+	assert.Equal(t, before.Mode().Perm(), after.Mode().Perm())
+}
@@ -0,0 +1,23 @@
+//go:build !unix
+
+package listener
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// listenUnix creates a Unix socket and then sets its permissions, as there
+// is no umask on this platform
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		lis.Close()
+		return nil, fmt.Errorf("unable to set mode of %s: %w", path, err)
+	}
+	return lis, nil
+}
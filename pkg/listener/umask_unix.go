@@ -0,0 +1,26 @@
+//go:build unix
+
+package listener
+
+import (
+	"net"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// umaskMu serializes the umask changes of listenUnix
+var umaskMu sync.Mutex
+
+// listenUnix creates a Unix socket with the given permissions. The umask
+// is set while the socket is created, so it never exists with wider
+// permissions as it would if they were changed afterwards. The umask is
+// process-wide, which is why listeners are best opened during startup.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	umaskMu.Lock()
+	defer umaskMu.Unlock()
+
+	old := syscall.Umask(int(0o777 &^ mode.Perm()))
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
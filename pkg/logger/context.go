@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// ctxKey is the context key under which the request-scoped logger is stored
+type ctxKey struct{}
+
+// WithContext returns a copy of ctx carrying the given logger
+func WithContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the request-scoped logger stored in ctx by the gRPC
+// interceptors, carrying fields such as method, request ID, peer address,
+// auth subject and trace IDs. It falls back to the global logger.
+func FromContext(ctx context.Context) *zap.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(*zap.Logger); ok {
+			return l
+		}
+	}
+	return GetLogger()
+}
+
+// AddFields returns a copy of ctx whose logger carries the additional fields
+func AddFields(ctx context.Context, fields ...zap.Field) context.Context {
+	return WithContext(ctx, FromContext(ctx).With(fields...))
+}
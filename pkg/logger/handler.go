@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// levelRequest is the body accepted by LevelHandler for PUT and POST
+type levelRequest struct {
+	Logger string `json:"logger"`
+	Level  string `json:"level"`
+	// TTL is a Go duration string such as "15m"
+	TTL string `json:"ttl"`
+}
+
+// LevelHandler returns an HTTP handler for inspecting and changing log levels
+// at runtime:
+//
+//	GET                          list the root level and per-logger overrides
+//	PUT/POST {"logger","level","ttl"}  set a level, temporarily when ttl is set
+//	DELETE ?logger=name          remove an override or cancel a temporary root level
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var req levelRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			level, err := zapcore.ParseLevel(req.Level)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var ttl time.Duration
+			if req.TTL != "" {
+				if ttl, err = time.ParseDuration(req.TTL); err != nil {
+					http.Error(w, "invalid ttl: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+				if ttl <= 0 {
+					http.Error(w, "invalid ttl: must be positive; omit it to change the level until reset", http.StatusBadRequest)
+					return
+				}
+			}
+			SetLevel(req.Logger, level, ttl)
+		case http.MethodDelete:
+			ResetLevel(r.URL.Query().Get("logger"))
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{"levels": Levels()}); err != nil {
+			Error("Failed to write response", zap.Error(err))
+		}
+	})
+}
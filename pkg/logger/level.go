@@ -0,0 +1,234 @@
+package logger
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LoggerLevel describes the level of the root logger or a named override
+type LoggerLevel struct {
+	// Logger is the logger name, empty for the root logger
+	Logger    string     `json:"logger"`
+	Level     string     `json:"level"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// levels holds the root log level and per-logger overrides
+var levels = newLevelRegistry(zapcore.InfoLevel)
+
+// levelEntry is the level of one logger. A temporary entry carries the timer
+// that restores the level it replaced.
+type levelEntry struct {
+	level     zapcore.Level
+	expiresAt time.Time
+	timer     *time.Timer
+	// revert is restored when the timer fires; nil removes the override
+	revert *zapcore.Level
+}
+
+// levelRegistry resolves the effective level of a logger by name. Reads go
+// through an immutable snapshot so the logging hot path never takes the lock.
+type levelRegistry struct {
+	mu      sync.Mutex
+	entries map[string]*levelEntry
+
+	snapshot atomic.Value // map[string]zapcore.Level
+	min      atomic.Int32
+}
+
+func newLevelRegistry(root zapcore.Level) *levelRegistry {
+	r := &levelRegistry{
+		entries: map[string]*levelEntry{"": {level: root}},
+	}
+	r.publish()
+	return r
+}
+
+// set changes the level of the named logger ("" for root). A positive ttl
+// restores the previous level once it expires.
+func (r *levelRegistry) set(name string, level zapcore.Level, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var revert *zapcore.Level
+	if prev, ok := r.entries[name]; ok {
+		r.stopTimer(prev)
+		revert = prev.revert
+		if prev.timer == nil {
+			// The previous level was permanent, so it is the one to restore
+			l := prev.level
+			revert = &l
+		}
+	}
+
+	entry := &levelEntry{level: level}
+	if ttl > 0 {
+		entry.revert = revert
+		entry.expiresAt = time.Now().Add(ttl)
+		entry.timer = time.AfterFunc(ttl, func() { r.expire(name, entry) })
+	}
+	r.entries[name] = entry
+	r.publish()
+}
+
+// reset removes the override of a named logger. For the root logger it
+// cancels a temporary level and restores the one it replaced.
+func (r *levelRegistry) reset(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[name]
+	if !ok {
+		return
+	}
+	r.stopTimer(entry)
+	r.restore(name, entry)
+	r.publish()
+}
+
+// expire restores the level replaced by a temporary entry, unless the entry
+// has been superseded in the meantime
+func (r *levelRegistry) expire(name string, entry *levelEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.entries[name] != entry {
+		return
+	}
+	r.restore(name, entry)
+	r.publish()
+}
+
+func (r *levelRegistry) restore(name string, entry *levelEntry) {
+	switch {
+	case entry.timer != nil && entry.revert != nil:
+		r.entries[name] = &levelEntry{level: *entry.revert}
+	case name == "":
+		// The root logger always keeps a level
+		r.entries[name] = &levelEntry{level: entry.level}
+	default:
+		delete(r.entries, name)
+	}
+}
+
+func (r *levelRegistry) stopTimer(entry *levelEntry) {
+	if entry.timer != nil {
+		entry.timer.Stop()
+	}
+}
+
+// publish rebuilds the lock-free snapshot. Callers must hold r.mu.
+func (r *levelRegistry) publish() {
+	snap := make(map[string]zapcore.Level, len(r.entries))
+	min := zapcore.InvalidLevel
+	for name, entry := range r.entries {
+		snap[name] = entry.level
+		if min == zapcore.InvalidLevel || entry.level < min {
+			min = entry.level
+		}
+	}
+	r.snapshot.Store(snap)
+	r.min.Store(int32(min))
+}
+
+// Enabled reports whether any logger could log at the given level
+func (r *levelRegistry) Enabled(level zapcore.Level) bool {
+	return level >= zapcore.Level(r.min.Load())
+}
+
+// enabledFor reports whether the named logger logs at the given level. The
+// longest matching dotted prefix wins, so an override for "repo" also applies
+// to "repo.sqlite" unless that has its own override.
+func (r *levelRegistry) enabledFor(name string, level zapcore.Level) bool {
+	snap := r.snapshot.Load().(map[string]zapcore.Level)
+	for {
+		if l, ok := snap[name]; ok {
+			return level >= l
+		}
+		if name == "" {
+			return true
+		}
+		if i := strings.LastIndexByte(name, '.'); i >= 0 {
+			name = name[:i]
+		} else {
+			name = ""
+		}
+	}
+}
+
+// list returns the root level followed by overrides sorted by name
+func (r *levelRegistry) list() []LoggerLevel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]LoggerLevel, 0, len(names))
+	for _, name := range names {
+		entry := r.entries[name]
+		ll := LoggerLevel{Logger: name, Level: entry.level.String()}
+		if entry.timer != nil {
+			expiresAt := entry.expiresAt
+			ll.ExpiresAt = &expiresAt
+		}
+		result = append(result, ll)
+	}
+	return result
+}
+
+// levelCore filters entries by the level of the logger that produced them
+type levelCore struct {
+	zapcore.Core
+	registry *levelRegistry
+}
+
+// Enabled implements zapcore.LevelEnabler
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.registry.Enabled(level)
+}
+
+// With implements zapcore.Core
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), registry: c.registry}
+}
+
+// Check implements zapcore.Core
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.registry.enabledFor(ent.LoggerName, ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// SetLevel changes the level of the named logger ("" for the root logger).
+// A positive ttl restores the previous level once it expires.
+func SetLevel(name string, level zapcore.Level, ttl time.Duration) {
+	levels.set(name, level, ttl)
+	Info("Log level changed",
+		zap.String("logger", name),
+		zap.String("level", level.String()),
+		zap.Duration("ttl", ttl),
+	)
+}
+
+// ResetLevel removes the override of the named logger. For the root logger
+// it cancels a temporary level and restores the level it replaced.
+func ResetLevel(name string) {
+	levels.reset(name)
+	Info("Log level reset", zap.String("logger", name))
+}
+
+// Levels returns the root level followed by per-logger overrides
+func Levels() []LoggerLevel {
+	return levels.list()
+}
@@ -1,10 +1,16 @@
 package logger
 
 import (
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/version"
 )
 
 var log *zap.Logger
@@ -22,13 +28,120 @@ func Init(env string) {
 		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	}
 
+	// Levels are enforced by the runtime level registry instead of the config
+	levels.set("", config.Level.Level(), 0)
+	config.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+
 	var err error
-	log, err = config.Build()
+	log, err = config.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelCore{Core: core, registry: levels}
+	}))
 	if err != nil {
 		panic(err)
 	}
 }
 
+// Setup initializes the logger from the application configuration: level,
+// encoding, output sinks, sampling and static service fields
+func Setup(cfg *config.Config) error {
+	level, err := zapcore.ParseLevel(cfg.App.LogLevel)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", cfg.App.LogLevel, err)
+	}
+
+	production := strings.EqualFold(cfg.App.Environment, "production")
+	encoder, err := newEncoder(cfg.Logging.Format, production)
+	if err != nil {
+		return err
+	}
+
+	sink, err := openOutputs(cfg.Logging.Outputs, cfg.Logging.Rotation)
+	if err != nil {
+		return err
+	}
+
+	// The core accepts every level; filtering happens in levelCore so the
+	// level can be changed at runtime, globally or per logger name
+	core := zapcore.NewCore(encoder, sink, zapcore.DebugLevel)
+	if s := cfg.Logging.Sampling; s.Enabled {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, s.Initial, s.Thereafter)
+	}
+	levels.set("", level, 0)
+	core = &levelCore{Core: core, registry: levels}
+
+	opts := []zap.Option{
+		zap.AddCaller(),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+	}
+	if !production {
+		opts = append(opts, zap.Development())
+	}
+
+	hostname, _ := os.Hostname()
+	log = zap.New(core, opts...).With(
+		zap.String("app", cfg.App.Name),
+		zap.String("version", version.Version),
+		zap.String("environment", strings.ToLower(cfg.App.Environment)),
+		zap.String("hostname", hostname),
+	)
+	return nil
+}
+
+// newEncoder builds the log encoder for the given format
+func newEncoder(format string, production bool) (zapcore.Encoder, error) {
+	encCfg := zap.NewDevelopmentEncoderConfig()
+	if production {
+		encCfg = zap.NewProductionEncoderConfig()
+		encCfg.TimeKey = "timestamp"
+		encCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	}
+
+	if format == "" {
+		format = "console"
+		if production {
+			format = "json"
+		}
+	}
+
+	switch strings.ToLower(format) {
+	case "json":
+		return zapcore.NewJSONEncoder(encCfg), nil
+	case "console":
+		if !production {
+			encCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		return zapcore.NewConsoleEncoder(encCfg), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// openOutputs opens every configured log sink and combines them
+func openOutputs(outputs []string, rotation config.LogRotationConfig) (zapcore.WriteSyncer, error) {
+	if len(outputs) == 0 {
+		outputs = []string{"stderr"}
+	}
+
+	syncers := make([]zapcore.WriteSyncer, 0, len(outputs))
+	for _, out := range outputs {
+		switch out {
+		case "stderr":
+			syncers = append(syncers, zapcore.Lock(os.Stderr))
+		case "stdout":
+			syncers = append(syncers, zapcore.Lock(os.Stdout))
+		default:
+			file, err := newRotatingFile(out, rotation)
+			if err != nil {
+				return nil, fmt.Errorf("unable to open log file %s: %w", out, err)
+			}
+			syncers = append(syncers, file)
+		}
+	}
+
+	return zapcore.NewMultiWriteSyncer(syncers...), nil
+}
+
 // GetLogger returns the singleton logger instance
 func GetLogger() *zap.Logger {
 	if log == nil {
@@ -42,6 +155,12 @@ func GetLogger() *zap.Logger {
 	return log
 }
 
+// Named returns a child logger with the given name. Its level can be
+// overridden at runtime with SetLevel, e.g. SetLevel("repo.sqlite", ...).
+func Named(name string) *zap.Logger {
+	return GetLogger().Named(name)
+}
+
 // Info logs a message at info level
 func Info(msg string, fields ...zapcore.Field) {
 	GetLogger().Info(msg, fields...)
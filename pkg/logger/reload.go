@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+// ApplyConfig applies the log level of a reloaded configuration. It is a
+// config.Watcher subscriber.
+func ApplyConfig(old, updated *config.Config) {
+	if old.App.LogLevel == updated.App.LogLevel {
+		return
+	}
+	level, err := zapcore.ParseLevel(updated.App.LogLevel)
+	if err != nil {
+		// Reloaded configurations are validated, so this is not expected
+		Error("Invalid log level in reloaded configuration", zap.String("level", updated.App.LogLevel))
+		return
+	}
+	SetLevel("", level, 0)
+}
+
+// LogReload logs the outcome of a configuration reload
+func LogReload(event config.ReloadEvent) {
+	if event.Err != nil {
+		Error("Configuration reload rejected, keeping the current configuration", zap.Error(event.Err))
+		return
+	}
+	if len(event.Applied) > 0 {
+		Info("Configuration reloaded", zap.Strings("applied", event.Applied))
+	}
+	if len(event.Ignored) > 0 {
+		Warn("Configuration changes ignored until restart", zap.Strings("settings", event.Ignored))
+	}
+}
+
+// WatchConfig starts reloading the configuration when its file or a secret
+// file changes.
+// The log level follows the file; further subscribers can be added to the
+// returned watcher, which is nil when watching could not start.
+func WatchConfig(cfg *config.Config) *config.Watcher {
+	watcher, err := config.Watch(cfg, LogReload)
+	if err != nil {
+		Warn("Configuration hot reload disabled", zap.Error(err))
+		return nil
+	}
+	watcher.Subscribe(ApplyConfig)
+	if files := watcher.Files(); len(files) > 0 {
+		Info("Watching configuration for changes", zap.Strings("files", files))
+	} else {
+		Info("Configuration hot reload disabled, no config file")
+	}
+	return watcher
+}
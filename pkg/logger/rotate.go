@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+// backupTimeFormat is appended to rotated log file names. The fixed-width
+// fraction keeps backups made within the same second apart and sorting
+// chronologically.
+const backupTimeFormat = "20060102T150405.000000000"
+
+// rotatingFile is a zapcore.WriteSyncer that rotates the underlying file when
+// it grows past a size limit or has been open longer than the rotation interval
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	interval   time.Duration
+	maxBackups int
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// newRotatingFile opens (or creates) the log file at path
+func newRotatingFile(path string, cfg config.LogRotationConfig) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	f := &rotatingFile{
+		path:       path,
+		maxSize:    int64(cfg.MaxSizeMB) * 1024 * 1024,
+		interval:   time.Duration(cfg.IntervalHours) * time.Hour,
+		maxBackups: cfg.MaxBackups,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes p to the current file, rotating first if required
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var rotateErr error
+	if f.file != nil && f.shouldRotate(int64(len(p))) {
+		rotateErr = f.rotate()
+	}
+	// A failed rotation or reopen leaves no file; try again on every write
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, errors.Join(rotateErr, err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Sync flushes the current file to disk
+func (f *rotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+func (f *rotatingFile) shouldRotate(incoming int64) bool {
+	if f.maxSize > 0 && f.size > 0 && f.size+incoming > f.maxSize {
+		return true
+	}
+	return f.interval > 0 && time.Since(f.openedAt) >= f.interval
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+	return nil
+}
+
+// rotate renames the current file with a timestamp suffix, opens a fresh one
+// and removes backups beyond the retention limit. The file at path is
+// reopened even when closing or renaming fails, so logging carries on in
+// the current file.
+func (f *rotatingFile) rotate() error {
+	closeErr := f.file.Close()
+	f.file = nil
+
+	backup := fmt.Sprintf("%s.%s", f.path, time.Now().Format(backupTimeFormat))
+	renameErr := os.Rename(f.path, backup)
+	if err := f.open(); err != nil {
+		return errors.Join(closeErr, renameErr, err)
+	}
+	if err := errors.Join(closeErr, renameErr); err != nil {
+		return err
+	}
+
+	return f.prune()
+}
+
+func (f *rotatingFile) prune() error {
+	if f.maxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return err
+	}
+	if len(backups) <= f.maxBackups {
+		return nil
+	}
+
+	// Timestamp suffixes sort chronologically
+	sort.Strings(backups)
+	for _, old := range backups[:len(backups)-f.maxBackups] {
+		if err := os.Remove(old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+func TestRotatingFileRotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := newRotatingFile(path, config.LogRotationConfig{MaxSizeMB: 1})
+	require.NoError(t, err)
+	f.maxSize = 10
+
+	// Each write past the limit rotates, several times within one second
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	backups, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	assert.Len(t, backups, 3)
+
+	var contents []string
+	for _, backup := range append(backups, path) {
+		data, err := os.ReadFile(backup)
+		require.NoError(t, err)
+		contents = append(contents, string(data))
+	}
+	assert.Equal(t, "first\nsecond\nthird\nfourth\n", strings.Join(contents, ""))
+}
+
+func TestRotatingFilePrunesBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := newRotatingFile(path, config.LogRotationConfig{MaxSizeMB: 1, MaxBackups: 2})
+	require.NoError(t, err)
+	f.maxSize = 1
+
+	for _, line := range []string{"1\n", "2\n", "3\n", "4\n", "5\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	backups, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	for i, want := range []string{"3\n", "4\n"} {
+		data, err := os.ReadFile(backups[i])
+		require.NoError(t, err)
+		assert.Equal(t, want, string(data))
+	}
+}
+
+func TestRotatingFileReopensAfterFailedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := newRotatingFile(path, config.LogRotationConfig{MaxSizeMB: 1})
+	require.NoError(t, err)
+	f.maxSize = 1
+	_, err = f.Write([]byte("first\n"))
+	require.NoError(t, err)
+
+	// Closing an already closed file fails, as would a failing disk
+	require.NoError(t, f.file.Close())
+	n, err := f.Write([]byte("second\n"))
+	assert.Error(t, err)
+	assert.Equal(t, len("second\n"), n)
+
+	_, err = f.Write([]byte("third\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Sync())
+}
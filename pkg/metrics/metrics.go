@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -49,17 +50,87 @@ var (
 		},
 		[]string{"method", "code"},
 	)
+
+	// HTTPRequestCounter counts HTTP requests by method, route template and status
+	HTTPRequestCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "The total number of HTTP requests received",
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	// HTTPRequestDuration tracks HTTP request latencies by method and route template
+	HTTPRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "The HTTP request latencies in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method", "route"},
+	)
+
+	// HTTPResponseSize tracks HTTP response body sizes by method and route template
+	HTTPResponseSize = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "The HTTP response body sizes in bytes",
+			Buckets: prometheus.ExponentialBuckets(100, 10, 6),
+		},
+		[]string{"method", "route"},
+	)
+
+	// HTTPActiveRequests tracks the number of HTTP requests in flight
+	HTTPActiveRequests = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "http_active_requests",
+			Help: "The number of active HTTP requests",
+		},
+	)
+
+	// UpstreamCircuitOpen is 1 while the gateway's upstream circuit breaker is open
+	UpstreamCircuitOpen = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gateway_upstream_circuit_open",
+			Help: "Whether the circuit breaker to the gRPC server is open",
+		},
+	)
 )
 
-// StartMetricsServer starts an HTTP server for Prometheus metrics
+// mux serves the metrics endpoint and any additional operational handlers
+var mux = http.NewServeMux()
+
+// Handle registers an additional handler on the metrics server, e.g. health
+// probes. It must be called before StartMetricsServer.
+func Handle(pattern string, handler http.Handler) {
+	mux.Handle(pattern, handler)
+}
+
+// StartMetricsServer starts an HTTP server for Prometheus metrics and the
+// runtime log level admin endpoint, which only answers local clients
 func StartMetricsServer(port int) {
-	http.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/admin/loglevel", localOnly(logger.LevelHandler()))
 	
 	addr := fmt.Sprintf(":%d", port)
 	go func() {
 		logger.Info("Starting metrics server", zap.String("address", addr))
-		if err := http.ListenAndServe(addr, nil); err != nil {
+		if err := http.ListenAndServe(addr, mux); err != nil {
 			logger.Error("Metrics server error", zap.Error(err))
 		}
 	}()
-} 
\ No newline at end of file
+} 
+
+// localOnly rejects requests that do not come from the loopback interface.
+// The address of the connection is used; forwarding headers are not, since
+// any client can set them.
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+			http.Error(w, "admin endpoints are only available from localhost", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
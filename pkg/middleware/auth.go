@@ -0,0 +1,262 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
+)
+
+// AuthPolicy decides which methods require credentials and verifies them.
+// It can be updated while the server is running.
+type AuthPolicy struct {
+	state atomic.Pointer[authState]
+}
+
+type authState struct {
+	enabled       bool
+	publicMethods map[string]bool
+	jwtSecret     config.Secret
+	apiKeys       []config.Secret
+}
+
+// apiKeyHeader carries an API key, an alternative to a bearer token
+const apiKeyHeader = "x-api-key"
+
+// NewAuthPolicy creates an authentication policy from the server configuration
+func NewAuthPolicy(cfg config.AuthConfig) *AuthPolicy {
+	p := &AuthPolicy{}
+	p.Update(cfg)
+	return p
+}
+
+// Update replaces the policy; calls in flight keep the decision already made
+func (p *AuthPolicy) Update(cfg config.AuthConfig) {
+	state := &authState{
+		enabled:       cfg.Enabled,
+		publicMethods: make(map[string]bool, len(cfg.PublicMethods)),
+		jwtSecret:     cfg.JWTSecret,
+		apiKeys:       cfg.APIKeys,
+	}
+	for _, m := range cfg.PublicMethods {
+		state.publicMethods[m] = true
+	}
+	p.state.Store(state)
+}
+
+// adminServicePrefix prefixes the methods of the admin API, which change the
+// server's behaviour and are never public
+const adminServicePrefix = "/admin.AdminService/"
+
+// Required reports whether calls to the given full method name must carry
+// credentials
+func (p *AuthPolicy) Required(method string) bool {
+	if p == nil || isAdminMethod(method) {
+		return true
+	}
+	state := p.state.Load()
+	return state.enabled && !state.publicMethods[method]
+}
+
+func isAdminMethod(method string) bool {
+	return strings.HasPrefix(method, adminServicePrefix)
+}
+
+// authorize checks the credentials of a call to the given full method name
+// and returns ctx carrying the authenticated subject. Credentials are a
+// bearer token in the authorization header or an API key in x-api-key.
+// Admin methods are allowed for callers on the same host and otherwise
+// need a token signed with the JWT secret or an API key, whether or not
+// authentication is enabled.
+func (p *AuthPolicy) authorize(ctx context.Context, method string) (context.Context, error) {
+	if !p.Required(method) {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := firstValue(md.Get("authorization"))
+	apiKey := firstValue(md.Get(apiKeyHeader))
+	admin := isAdminMethod(method)
+
+	switch {
+	case header == "" && apiKey == "":
+		if admin && isLocalPeer(ctx) {
+			return ctx, nil
+		}
+		if admin && !p.secret().IsSet() && len(p.keys()) == 0 {
+			return ctx, errAdminLocalOnly
+		}
+		return ctx, status.Error(codes.Unauthenticated, "missing authorization header")
+	case header == "":
+		if !p.validAPIKey(apiKey) {
+			return ctx, status.Error(codes.Unauthenticated, "invalid API key")
+		}
+		return ctx, nil
+	case admin && !p.secret().IsSet():
+		// Without a secret any token would pass
+		if isLocalPeer(ctx) {
+			return ctx, nil
+		}
+		return ctx, errAdminLocalOnly
+	}
+
+	subject, err := p.authenticate(header)
+	if err != nil {
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if subject != "" {
+		ctx = logger.AddFields(ctx, zap.String("subject", subject))
+	}
+	return ctx, nil
+}
+
+var errAdminLocalOnly = status.Error(codes.PermissionDenied, "admin API is only available to local callers unless server.auth.jwt_secret or server.auth.api_keys is set")
+
+// validAPIKey reports whether key is one of the configured API keys
+func (p *AuthPolicy) validAPIKey(key string) bool {
+	valid := false
+	for _, k := range p.keys() {
+		// Compare with every key in constant time so timing reveals nothing
+		if k.IsSet() && subtle.ConstantTimeCompare([]byte(k.Value()), []byte(key)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+func (p *AuthPolicy) keys() []config.Secret {
+	if p == nil {
+		return nil
+	}
+	return p.state.Load().apiKeys
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// isLocalPeer reports whether the caller connected over a Unix socket or
+// the loopback interface
+func isLocalPeer(ctx context.Context) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return false
+	}
+	if p.Addr.Network() == "unix" {
+		return true
+	}
+	// In single-port mode the address is only known as a string, in which
+	// net/http reports Unix socket peers as "@"
+	addr := p.Addr.String()
+	if addr == "@" {
+		return true
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (p *AuthPolicy) secret() config.Secret {
+	if p == nil {
+		return config.Secret{}
+	}
+	return p.state.Load().jwtSecret
+}
+
+// authenticate validates an authorization header value and returns the
+// subject it identifies. Without a JWT secret any non-empty token is
+// accepted without a known subject.
+func (p *AuthPolicy) authenticate(header string) (string, error) {
+	token := header
+	if scheme, rest, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		token = strings.TrimSpace(rest)
+	}
+	if token == "" {
+		return "", errors.New("invalid token")
+	}
+
+	secret := p.secret()
+	if !secret.IsSet() {
+		return "", nil
+	}
+	return verifyJWT(token, []byte(secret.Value()), time.Now())
+}
+
+// jwtClaims are the registered claims checked on tokens
+type jwtClaims struct {
+	Subject   string   `json:"sub"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+}
+
+// verifyJWT checks the signature and validity period of an HS256-signed
+// JSON Web Token and returns its subject
+func verifyJWT(token string, key []byte, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("invalid token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return "", errors.New("invalid token")
+	}
+	if header.Alg != "HS256" {
+		return "", errors.New("invalid token: unsupported signing algorithm")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.New("invalid token")
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", errors.New("invalid token: bad signature")
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return "", errors.New("invalid token")
+	}
+	unix := float64(now.Unix())
+	if claims.ExpiresAt != nil && unix >= *claims.ExpiresAt {
+		return "", errors.New("invalid token: expired")
+	}
+	if claims.NotBefore != nil && unix < *claims.NotBefore {
+		return "", errors.New("invalid token: not valid yet")
+	}
+	return claims.Subject, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
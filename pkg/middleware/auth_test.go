@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+const (
+	setLogLevelMethod = "/admin.AdminService/SetLogLevel"
+	getUserMethod     = "/user.UserService/GetUser"
+)
+
+func TestAuthPolicyAuthorize(t *testing.T) {
+	remote := &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 40000}
+	loopback := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 40000}
+	socket := &net.UnixAddr{Name: "/run/app/grpc.sock", Net: "unix"}
+	httpLoopback := httpAddr("127.0.0.1:40000")
+	httpSocket := httpAddr("@")
+	secret := config.NewSecret("0123456789abcdef0123456789abcdef")
+	keys := []config.Secret{config.NewSecret("first-key-0123456789"), config.NewSecret("second-key-0123456789")}
+
+	tests := []struct {
+		name   string
+		cfg    config.AuthConfig
+		method string
+		addr   net.Addr
+		header string
+		apiKey string
+		want   codes.Code
+	}{
+		{"disabled", config.AuthConfig{}, getUserMethod, remote, "", "", codes.OK},
+		{"public method", config.AuthConfig{Enabled: true, PublicMethods: []string{getUserMethod}}, getUserMethod, remote, "", "", codes.OK},
+		{"missing token", config.AuthConfig{Enabled: true}, getUserMethod, remote, "", "", codes.Unauthenticated},
+		{"any token without secret", config.AuthConfig{Enabled: true}, getUserMethod, remote, "Bearer opaque", "", codes.OK},
+		{"admin from loopback", config.AuthConfig{}, setLogLevelMethod, loopback, "", "", codes.OK},
+		{"admin from unix socket", config.AuthConfig{}, setLogLevelMethod, socket, "", "", codes.OK},
+		{"admin from loopback over HTTP", config.AuthConfig{}, setLogLevelMethod, httpLoopback, "", "", codes.OK},
+		{"admin from unix socket over HTTP", config.AuthConfig{}, setLogLevelMethod, httpSocket, "", "", codes.OK},
+		{"admin remote without secret", config.AuthConfig{}, setLogLevelMethod, remote, "Bearer opaque", "", codes.PermissionDenied},
+		{"admin remote public", config.AuthConfig{Enabled: true, PublicMethods: []string{setLogLevelMethod}}, setLogLevelMethod, remote, "", "", codes.PermissionDenied},
+		{"admin remote missing token", config.AuthConfig{JWTSecret: secret}, setLogLevelMethod, remote, "", "", codes.Unauthenticated},
+		{"admin remote unsigned token", config.AuthConfig{JWTSecret: secret}, setLogLevelMethod, remote, "Bearer opaque", "", codes.Unauthenticated},
+		{"admin remote signed token", config.AuthConfig{JWTSecret: secret}, setLogLevelMethod, remote, "Bearer " + signJWT(t, secret.Value(), `{"sub":"ops"}`), "", codes.OK},
+		{"api key", config.AuthConfig{Enabled: true, APIKeys: keys}, getUserMethod, remote, "", "second-key-0123456789", codes.OK},
+		{"wrong api key", config.AuthConfig{Enabled: true, APIKeys: keys}, getUserMethod, remote, "", "third-key-0123456789", codes.Unauthenticated},
+		{"api key without keys", config.AuthConfig{Enabled: true}, getUserMethod, remote, "", "first-key-0123456789", codes.Unauthenticated},
+		{"admin remote api key", config.AuthConfig{APIKeys: keys}, setLogLevelMethod, remote, "", "first-key-0123456789", codes.OK},
+		{"admin remote missing api key", config.AuthConfig{APIKeys: keys}, setLogLevelMethod, remote, "", "", codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: tt.addr})
+			md := metadata.MD{}
+			if tt.header != "" {
+				md.Set("authorization", tt.header)
+			}
+			if tt.apiKey != "" {
+				md.Set(apiKeyHeader, tt.apiKey)
+			}
+			ctx = metadata.NewIncomingContext(ctx, md)
+
+			_, err := NewAuthPolicy(tt.cfg).authorize(ctx, tt.method)
+			assert.Equal(t, tt.want, status.Code(err), "error: %v", err)
+		})
+	}
+}
+
+// httpAddr is a peer address known only as the string net/http reports,
+// as in single-port mode
+type httpAddr string
+
+func (a httpAddr) Network() string { return "tcp" }
+func (a httpAddr) String() string  { return string(a) }
+
+// signJWT returns an HS256 token with the given claims
+func signJWT(t *testing.T, key, claims string) string {
+	t.Helper()
+	return signJWTHeader(t, `{"alg":"HS256","typ":"JWT"}`, key, claims)
+}
+
+// signJWTHeader signs a token with HMAC-SHA256 whatever its header claims
+func signJWTHeader(t *testing.T, header, key, claims string) string {
+	t.Helper()
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(header)) +
+		"." + base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyJWT(t *testing.T) {
+	const key = "0123456789abcdef0123456789abcdef"
+	now := time.Unix(1700000000, 0)
+	valid := signJWT(t, key, `{"sub":"alice"}`)
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		subject string
+		wantErr string
+	}{
+		{"valid", valid, "alice", ""},
+		{"within validity period", signJWT(t, key, `{"sub":"bob","nbf":1699999000,"exp":1700001000}`), "bob", ""},
+		{"valid from now", signJWT(t, key, `{"sub":"bob","nbf":1700000000}`), "bob", ""},
+		{"fractional expiry", signJWT(t, key, `{"sub":"bob","exp":1700000000.5}`), "bob", ""},
+		{"no subject", signJWT(t, key, `{}`), "", ""},
+		{"expired", signJWT(t, key, `{"sub":"bob","exp":1699999999}`), "", "expired"},
+		{"expires now", signJWT(t, key, `{"sub":"bob","exp":1700000000}`), "", "expired"},
+		{"not valid yet", signJWT(t, key, `{"sub":"bob","nbf":1700000001}`), "", "not valid yet"},
+		{"other key", signJWT(t, "fedcba9876543210fedcba9876543210", `{"sub":"alice"}`), "", "bad signature"},
+		{"altered claims", parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"root"}`)) + "." + parts[2], "", "bad signature"},
+		{"unsigned", signJWTHeader(t, `{"alg":"none"}`, key, `{"sub":"alice"}`), "", "unsupported signing algorithm"},
+		{"other algorithm", signJWTHeader(t, `{"alg":"HS512"}`, key, `{"sub":"alice"}`), "", "unsupported signing algorithm"},
+		{"claims not json", signJWT(t, key, `alice`), "", "invalid token"},
+		{"header not json", signJWTHeader(t, `HS256`, key, `{"sub":"alice"}`), "", "invalid token"},
+		{"signature not base64", parts[0] + "." + parts[1] + ".!!", "", "invalid token"},
+		{"two parts", parts[0] + "." + parts[1], "", "invalid token"},
+		{"empty", "", "", "invalid token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, err := verifyJWT(tt.token, []byte(key), now)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.subject, subject)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+// Deadlines holds the server-side deadlines applied to calls that arrive
+// without one
+type Deadlines struct {
+	unary   time.Duration
+	methods map[string]time.Duration
+}
+
+// NewDeadlines creates deadlines from the server configuration: the write
+// timeout for unary calls and per-method overrides
+func NewDeadlines(cfg config.ServerConfig) *Deadlines {
+	d := &Deadlines{
+		unary:   time.Duration(cfg.WriteTimeout) * time.Second,
+		methods: make(map[string]time.Duration, len(cfg.Timeouts)),
+	}
+	// Viper lower-cases map keys, so overrides match method names in any case
+	for name, timeout := range cfg.Timeouts {
+		d.methods[strings.ToLower(name)] = time.Duration(timeout) * time.Second
+	}
+	return d
+}
+
+// timeout returns the deadline for calls to the given full method name
+// without one of their own; 0 means none. Streams only get a deadline when
+// their method is listed, since they may stay open indefinitely.
+func (d *Deadlines) timeout(method string, stream bool) time.Duration {
+	if timeout, ok := d.methods[strings.ToLower(method[strings.LastIndexByte(method, '/')+1:])]; ok {
+		return timeout
+	}
+	if stream {
+		return 0
+	}
+	return d.unary
+}
+
+// withDeadline applies the method's timeout unless ctx already has a deadline
+func (d *Deadlines) withDeadline(ctx context.Context, method string, stream bool) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	if timeout := d.timeout(method, stream); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {}
+}
+
+// DeadlineInterceptor returns a gRPC unary server interceptor that sets a
+// deadline on calls without one
+func DeadlineInterceptor(deadlines *Deadlines) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := deadlines.withDeadline(ctx, info.FullMethod, false)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// DeadlineStreamInterceptor returns a gRPC stream server interceptor that
+// sets a deadline on streams without one whose method has a timeout
+func DeadlineStreamInterceptor(deadlines *Deadlines) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := deadlines.withDeadline(ss.Context(), info.FullMethod, true)
+		defer cancel()
+		if ctx != ss.Context() {
+			ss = &contextServerStream{ServerStream: ss, ctx: ctx}
+		}
+		return handler(srv, ss)
+	}
+}
@@ -3,19 +3,22 @@ package middleware
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
 )
 
-// LoggingInterceptor returns a gRPC unary server interceptor for logging requests
-func LoggingInterceptor() grpc.UnaryServerInterceptor {
+// LoggingInterceptor returns a gRPC unary server interceptor for logging requests.
+// Request and response payloads are logged according to the given policy.
+func LoggingInterceptor(policy *PayloadPolicy) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		startTime := time.Now()
 		
@@ -23,14 +26,13 @@ func LoggingInterceptor() grpc.UnaryServerInterceptor {
 		md, _ := metadata.FromIncomingContext(ctx)
 		requestID := extractRequestID(md)
 		
-		// Create a logger for this request
-		logFields := []zap.Field{
-			zap.String("method", info.FullMethod),
-			zap.String("request_id", requestID),
-			zap.Any("request", req),
-		}
+		// Create a logger for this request and make it available to handlers
+		reqLogger := logger.GetLogger().With(requestFields(ctx, md, info.FullMethod, requestID)...)
+		ctx = logger.WithContext(ctx, reqLogger)
 		
-		logger.Info("Received gRPC request", logFields...)
+		logFields := policy.Fields(info.FullMethod, "request", req)
+		
+		reqLogger.Info("Received gRPC request", logFields...)
 		
 		// Call the handler
 		resp, err := handler(ctx, req)
@@ -48,10 +50,10 @@ func LoggingInterceptor() grpc.UnaryServerInterceptor {
 				zap.String("error", err.Error()),
 				zap.String("error_code", st.Code().String()),
 			)
-			logger.Error("gRPC request error", responseFields...)
+			reqLogger.Error("gRPC request error", responseFields...)
 		} else {
-			responseFields = append(responseFields, zap.Any("response", resp))
-			logger.Info("gRPC request completed", responseFields...)
+			responseFields = append(responseFields, policy.Fields(info.FullMethod, "response", resp)...)
+			reqLogger.Info("gRPC request completed", responseFields...)
 		}
 		
 		return resp, err
@@ -68,15 +70,16 @@ func LoggingStreamInterceptor() grpc.StreamServerInterceptor {
 		md, _ := metadata.FromIncomingContext(ctx)
 		requestID := extractRequestID(md)
 		
-		// Create a logger for this request
+		// Create a logger for this request and make it available to handlers
+		reqLogger := logger.GetLogger().With(requestFields(ctx, md, info.FullMethod, requestID)...)
+		ss = &contextServerStream{ServerStream: ss, ctx: logger.WithContext(ctx, reqLogger)}
+		
 		logFields := []zap.Field{
-			zap.String("method", info.FullMethod),
-			zap.String("request_id", requestID),
 			zap.Bool("is_client_stream", info.IsClientStream),
 			zap.Bool("is_server_stream", info.IsServerStream),
 		}
 		
-		logger.Info("Received gRPC stream request", logFields...)
+		reqLogger.Info("Received gRPC stream request", logFields...)
 		
 		// Call the handler
 		err := handler(srv, ss)
@@ -94,9 +97,9 @@ func LoggingStreamInterceptor() grpc.StreamServerInterceptor {
 				zap.String("error", err.Error()),
 				zap.String("error_code", st.Code().String()),
 			)
-			logger.Error("gRPC stream request error", responseFields...)
+			reqLogger.Error("gRPC stream request error", responseFields...)
 		} else {
-			logger.Info("gRPC stream request completed", responseFields...)
+			reqLogger.Info("gRPC stream request completed", responseFields...)
 		}
 		
 		return err
@@ -104,29 +107,11 @@ func LoggingStreamInterceptor() grpc.StreamServerInterceptor {
 }
 
 // AuthInterceptor returns a gRPC unary server interceptor for authentication
-func AuthInterceptor() grpc.UnaryServerInterceptor {
+func AuthInterceptor(policy *AuthPolicy) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		// Skip authentication for specific methods
-		if isPublicMethod(info.FullMethod) {
-			return handler(ctx, req)
-		}
-		
-		// Extract token from metadata
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			return nil, status.Error(codes.Unauthenticated, "missing metadata")
-		}
-		
-		authHeader, ok := md["authorization"]
-		if !ok || len(authHeader) == 0 {
-			return nil, status.Error(codes.Unauthenticated, "missing authorization header")
-		}
-		
-		token := authHeader[0]
-		
-		// Validate token (implement your own validation logic)
-		if !validateToken(token) {
-			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		ctx, err := policy.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
 		}
 		
 		// Call the handler
@@ -135,30 +120,14 @@ func AuthInterceptor() grpc.UnaryServerInterceptor {
 }
 
 // AuthStreamInterceptor returns a gRPC stream server interceptor for authentication
-func AuthStreamInterceptor() grpc.StreamServerInterceptor {
+func AuthStreamInterceptor(policy *AuthPolicy) grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		// Skip authentication for specific methods
-		if isPublicMethod(info.FullMethod) {
-			return handler(srv, ss)
-		}
-		
-		// Extract token from metadata
-		ctx := ss.Context()
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			return status.Error(codes.Unauthenticated, "missing metadata")
-		}
-		
-		authHeader, ok := md["authorization"]
-		if !ok || len(authHeader) == 0 {
-			return status.Error(codes.Unauthenticated, "missing authorization header")
+		ctx, err := policy.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
 		}
-		
-		token := authHeader[0]
-		
-		// Validate token (implement your own validation logic)
-		if !validateToken(token) {
-			return status.Error(codes.Unauthenticated, "invalid token")
+		if ctx != ss.Context() {
+			ss = &contextServerStream{ServerStream: ss, ctx: ctx}
 		}
 		
 		// Call the handler
@@ -201,11 +170,9 @@ func RecoveryStreamInterceptor() grpc.StreamServerInterceptor {
 }
 
 // RateLimitInterceptor returns a gRPC unary server interceptor for rate limiting
-func RateLimitInterceptor() grpc.UnaryServerInterceptor {
-	// In a production system, you would use a proper rate limiter like token bucket
+func RateLimitInterceptor(limiter *RateLimiter) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		// Simple rate limiting logic (this is just a placeholder)
-		if isRateLimited(info.FullMethod) {
+		if !limiter.Allow(info.FullMethod) {
 			return nil, status.Error(codes.ResourceExhausted, "Rate limit exceeded")
 		}
 		
@@ -214,11 +181,9 @@ func RateLimitInterceptor() grpc.UnaryServerInterceptor {
 }
 
 // RateLimitStreamInterceptor returns a gRPC stream server interceptor for rate limiting
-func RateLimitStreamInterceptor() grpc.StreamServerInterceptor {
-	// In a production system, you would use a proper rate limiter like token bucket
+func RateLimitStreamInterceptor(limiter *RateLimiter) grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		// Simple rate limiting logic (this is just a placeholder)
-		if isRateLimited(info.FullMethod) {
+		if !limiter.Allow(info.FullMethod) {
 			return status.Error(codes.ResourceExhausted, "Rate limit exceeded")
 		}
 		
@@ -235,24 +200,52 @@ func extractRequestID(md metadata.MD) string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
 }
 
-func isPublicMethod(method string) bool {
-	// Define which methods are public and don't require authentication
-	publicMethods := map[string]bool{
-		"/user.UserService/GetUser": false,
+// requestFields returns the request-scoped log fields: method, request ID,
+// peer address, the client chain reported by the gateway and trace IDs
+// propagated by the caller
+func requestFields(ctx context.Context, md metadata.MD, method, requestID string) []zap.Field {
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("request_id", requestID),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, zap.String("peer", p.Addr.String()))
 	}
-	
-	isPublic, exists := publicMethods[method]
-	return exists && isPublic
+	if values := md.Get("x-forwarded-for"); len(values) > 0 {
+		fields = append(fields, zap.String("forwarded_for", values[0]))
+	}
+	return append(fields, traceFields(md)...)
 }
 
-func validateToken(token string) bool {
-	// Implement your token validation logic here
-	// This is just a placeholder
-	return len(token) > 0
+// traceFields extracts trace and span IDs from W3C traceparent or B3 headers
+func traceFields(md metadata.MD) []zap.Field {
+	if values := md.Get("traceparent"); len(values) > 0 {
+		// version-traceid-spanid-flags
+		parts := strings.Split(values[0], "-")
+		if len(parts) == 4 {
+			return []zap.Field{
+				zap.String("trace_id", parts[1]),
+				zap.String("span_id", parts[2]),
+			}
+		}
+	}
+	if values := md.Get("x-b3-traceid"); len(values) > 0 {
+		fields := []zap.Field{zap.String("trace_id", values[0])}
+		if spans := md.Get("x-b3-spanid"); len(spans) > 0 {
+			fields = append(fields, zap.String("span_id", spans[0]))
+		}
+		return fields
+	}
+	return nil
 }
 
-func isRateLimited(method string) bool {
-	// Implement your rate limiting logic here
-	// This is just a placeholder
-	return false
-} 
\ No newline at end of file
+// contextServerStream overrides the context of a grpc.ServerStream
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the overridden context
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+// Payload logging levels
+const (
+	// BodyLevelNone logs no payload information
+	BodyLevelNone = "none"
+	// BodyLevelMetadata logs the payload type and size only
+	BodyLevelMetadata = "metadata"
+	// BodyLevelFull logs the redacted payload
+	BodyLevelFull = "full"
+)
+
+// Redaction modes
+const (
+	// RedactModeMask replaces sensitive values with a fixed placeholder
+	RedactModeMask = "mask"
+	// RedactModeHash replaces sensitive values with a truncated SHA-256 digest,
+	// which keeps values correlatable across log lines without exposing them
+	RedactModeHash = "hash"
+)
+
+const redactedPlaceholder = "[REDACTED]"
+
+// PayloadPolicy decides how request and response payloads are written to logs
+type PayloadPolicy struct {
+	defaultLevel string
+	methodLevels map[string]string
+	redactFields map[string]bool
+	hash         bool
+	maxBytes     int
+}
+
+// NewPayloadPolicy creates a payload logging policy from the logging configuration
+func NewPayloadPolicy(cfg config.LoggingConfig) *PayloadPolicy {
+	p := &PayloadPolicy{
+		defaultLevel: normalizeBodyLevel(cfg.BodyLevel),
+		methodLevels: make(map[string]string, len(cfg.MethodBodyLevels)),
+		redactFields: make(map[string]bool, len(cfg.RedactFields)),
+		hash:         strings.EqualFold(cfg.RedactMode, RedactModeHash),
+		maxBytes:     cfg.MaxPayloadBytes,
+	}
+
+	for _, m := range cfg.MethodBodyLevels {
+		p.methodLevels[m.Method] = normalizeBodyLevel(m.Level)
+	}
+	for _, f := range cfg.RedactFields {
+		p.redactFields[f] = true
+	}
+
+	return p
+}
+
+// Level returns the payload logging level for the given gRPC method
+func (p *PayloadPolicy) Level(method string) string {
+	if p == nil {
+		return BodyLevelMetadata
+	}
+	if level, ok := p.methodLevels[method]; ok {
+		return level
+	}
+	return p.defaultLevel
+}
+
+// Fields returns the log fields describing a payload under the given key
+func (p *PayloadPolicy) Fields(method, key string, payload interface{}) []zap.Field {
+	if payload == nil {
+		return nil
+	}
+
+	switch p.Level(method) {
+	case BodyLevelNone:
+		return nil
+	case BodyLevelMetadata:
+		fields := []zap.Field{zap.String(key+"_type", fmt.Sprintf("%T", payload))}
+		if msg, ok := payload.(proto.Message); ok {
+			fields = append(fields, zap.Int(key+"_size", proto.Size(msg)))
+		}
+		return fields
+	}
+
+	body := p.render(payload)
+	if p.maxBytes > 0 && len(body) > p.maxBytes {
+		return []zap.Field{
+			zap.String(key, truncate(body, p.maxBytes)),
+			zap.Bool(key+"_truncated", true),
+			zap.Int(key+"_size", len(body)),
+		}
+	}
+	return []zap.Field{zap.String(key, body)}
+}
+
+// truncate cuts s to at most n bytes, backing off to the start of a rune so
+// a multi-byte character is never split
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// render returns the redacted JSON representation of a payload
+func (p *PayloadPolicy) render(payload interface{}) string {
+	msg, ok := payload.(proto.Message)
+	if !ok {
+		// Non-proto payloads cannot be inspected for sensitive fields
+		return fmt.Sprintf("%T", payload)
+	}
+
+	if len(p.redactFields) > 0 {
+		msg = proto.Clone(msg)
+		p.redact(msg.ProtoReflect())
+	}
+
+	body, err := protojson.Marshal(msg)
+	if err != nil {
+		return fmt.Sprintf("<unmarshalable %T: %v>", payload, err)
+	}
+	return string(body)
+}
+
+// redact masks sensitive fields of m in place, descending into nested messages
+func (p *PayloadPolicy) redact(m protoreflect.Message) {
+	var sensitive []protoreflect.FieldDescriptor
+
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if p.isSensitive(fd) {
+			sensitive = append(sensitive, fd)
+			return true
+		}
+
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Kind() == protoreflect.MessageKind {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					p.redact(mv.Message())
+					return true
+				})
+			}
+		case fd.IsList():
+			if fd.Kind() == protoreflect.MessageKind {
+				list := v.List()
+				for i := 0; i < list.Len(); i++ {
+					p.redact(list.Get(i).Message())
+				}
+			}
+		case fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind:
+			p.redact(v.Message())
+		}
+		return true
+	})
+
+	for _, fd := range sensitive {
+		if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+			m.Set(fd, protoreflect.ValueOfString(p.mask(m.Get(fd).String())))
+			continue
+		}
+		// Non-string sensitive fields are dropped entirely
+		m.Clear(fd)
+	}
+}
+
+// isSensitive reports whether a field is configured for redaction
+func (p *PayloadPolicy) isSensitive(fd protoreflect.FieldDescriptor) bool {
+	return p.redactFields[string(fd.Name())] || p.redactFields[string(fd.FullName())]
+}
+
+// mask replaces a sensitive value according to the redaction mode
+func (p *PayloadPolicy) mask(value string) string {
+	if !p.hash {
+		return redactedPlaceholder
+	}
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:])[:16]
+}
+
+// normalizeBodyLevel maps a configured level to a known level. An unknown
+// level falls back to metadata so a typo never logs full payloads.
+func normalizeBodyLevel(level string) string {
+	switch strings.ToLower(level) {
+	case BodyLevelNone:
+		return BodyLevelNone
+	case BodyLevelFull:
+		return BodyLevelFull
+	default:
+		return BodyLevelMetadata
+	}
+}
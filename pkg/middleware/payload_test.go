@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/user"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+func TestPayloadPolicyLevel(t *testing.T) {
+	policy := NewPayloadPolicy(config.LoggingConfig{
+		BodyLevel: "FULL",
+		MethodBodyLevels: []config.MethodBodyLevel{
+			{Method: "/user.UserService/CreateUser", Level: "none"},
+			{Method: "/user.UserService/GetUser", Level: "Metadata"},
+			{Method: "/user.UserService/ListUsers", Level: "verbose"},
+		},
+	})
+
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{"/user.UserService/CreateUser", BodyLevelNone},
+		{"/user.UserService/GetUser", BodyLevelMetadata},
+		{"/user.UserService/ListUsers", BodyLevelMetadata},
+		{"/user.UserService/DeleteUser", BodyLevelFull},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.Level(tt.method))
+		})
+	}
+
+	var nilPolicy *PayloadPolicy
+	assert.Equal(t, BodyLevelMetadata, nilPolicy.Level("/user.UserService/GetUser"))
+}
+
+func TestNormalizeBodyLevel(t *testing.T) {
+	tests := []struct {
+		level string
+		want  string
+	}{
+		{"none", BodyLevelNone},
+		{"NONE", BodyLevelNone},
+		{"metadata", BodyLevelMetadata},
+		{"full", BodyLevelFull},
+		{"Full", BodyLevelFull},
+		{"", BodyLevelMetadata},
+		{"fulll", BodyLevelMetadata},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizeBodyLevel(tt.level))
+		})
+	}
+}
+
+func TestPayloadPolicyRedaction(t *testing.T) {
+	user := func() *pb.UserResponse {
+		return &pb.UserResponse{Id: "1", Name: "Ada", Email: "ada@example.com"}
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config.LoggingConfig
+		payload interface{}
+		want    string
+	}{
+		{
+			name:    "no redaction",
+			cfg:     config.LoggingConfig{BodyLevel: "full"},
+			payload: user(),
+			want:    `{"id":"1","name":"Ada","email":"ada@example.com"}`,
+		},
+		{
+			name:    "mask by field name",
+			cfg:     config.LoggingConfig{BodyLevel: "full", RedactFields: []string{"email"}},
+			payload: user(),
+			want:    `{"id":"1","name":"Ada","email":"[REDACTED]"}`,
+		},
+		{
+			name:    "mask by full name",
+			cfg:     config.LoggingConfig{BodyLevel: "full", RedactFields: []string{"user.UserResponse.name"}},
+			payload: user(),
+			want:    `{"id":"1","name":"[REDACTED]","email":"ada@example.com"}`,
+		},
+		{
+			name:    "full name of another message",
+			cfg:     config.LoggingConfig{BodyLevel: "full", RedactFields: []string{"user.CreateUserRequest.email"}},
+			payload: user(),
+			want:    `{"id":"1","name":"Ada","email":"ada@example.com"}`,
+		},
+		{
+			name:    "hash",
+			cfg:     config.LoggingConfig{BodyLevel: "full", RedactFields: []string{"email"}, RedactMode: "hash"},
+			payload: user(),
+			want:    `{"id":"1","name":"Ada","email":"sha256:b5fc85e55755f9e0"}`,
+		},
+		{
+			name:    "nested in list",
+			cfg:     config.LoggingConfig{BodyLevel: "full", RedactFields: []string{"email"}},
+			payload: &pb.ListUsersResponse{Users: []*pb.UserResponse{user(), user()}},
+			want:    `{"users":[{"id":"1","name":"Ada","email":"[REDACTED]"},{"id":"1","name":"Ada","email":"[REDACTED]"}]}`,
+		},
+		{
+			name:    "non-string field dropped",
+			cfg:     config.LoggingConfig{BodyLevel: "full", RedactFields: []string{"users"}},
+			payload: &pb.ListUsersResponse{Users: []*pb.UserResponse{user()}, NextPageToken: "next"},
+			want:    `{"nextPageToken":"next"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewPayloadPolicy(tt.cfg).render(tt.payload)
+			assert.JSONEq(t, tt.want, got)
+		})
+	}
+}
+
+func TestPayloadPolicyRedactionKeepsPayload(t *testing.T) {
+	policy := NewPayloadPolicy(config.LoggingConfig{BodyLevel: "full", RedactFields: []string{"email"}})
+	payload := &pb.UserResponse{Id: "1", Email: "ada@example.com"}
+
+	policy.render(payload)
+
+	assert.Equal(t, "ada@example.com", payload.Email)
+}
+
+func TestPayloadPolicyNonProtoPayload(t *testing.T) {
+	policy := NewPayloadPolicy(config.LoggingConfig{BodyLevel: "full", RedactFields: []string{"email"}})
+
+	got := policy.render(struct{ Email string }{"ada@example.com"})
+
+	assert.Equal(t, "struct { Email string }", got)
+}
+
+func TestPayloadPolicyFields(t *testing.T) {
+	payload := &pb.UserResponse{Id: "1", Name: "Ada"}
+
+	tests := []struct {
+		name string
+		cfg  config.LoggingConfig
+		want []zap.Field
+	}{
+		{
+			name: "none",
+			cfg:  config.LoggingConfig{BodyLevel: "none"},
+			want: nil,
+		},
+		{
+			name: "metadata",
+			cfg:  config.LoggingConfig{BodyLevel: "metadata"},
+			want: []zap.Field{
+				zap.String("request_type", "*user.UserResponse"),
+				zap.Int("request_size", 8),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewPayloadPolicy(tt.cfg).Fields("/user.UserService/GetUser", "request", payload)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"Ada", 10, "Ada"},
+		{"Ada", 3, "Ada"},
+		{"Ada Lovelace", 3, "Ada"},
+		{"Zoë", 3, "Zo"},
+		{"Zoë", 4, "Zoë"},
+		{"日本", 2, ""},
+		{"日本", 4, "日"},
+		{"😀x", 3, ""},
+		{"", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got := truncate(tt.s, tt.n)
+			assert.Equal(t, tt.want, got)
+			assert.True(t, utf8.ValidString(got))
+		})
+	}
+}
+
+func TestPayloadPolicyFieldsTruncated(t *testing.T) {
+	policy := NewPayloadPolicy(config.LoggingConfig{BodyLevel: "full", MaxPayloadBytes: 10})
+
+	got := policy.Fields("/user.UserService/GetUser", "request", &pb.UserResponse{Id: "1", Name: "Ada"})
+
+	// protojson output is not byte-stable, so only the shape is checked
+	if assert.Len(t, got, 3) {
+		assert.Equal(t, "request", got[0].Key)
+		assert.Len(t, got[0].String, 10)
+		assert.Equal(t, zap.Bool("request_truncated", true), got[1])
+		assert.Equal(t, "request_size", got[2].Key)
+		assert.Greater(t, got[2].Integer, int64(10))
+	}
+}
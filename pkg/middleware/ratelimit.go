@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+// RateLimiter limits calls with a token bucket per gRPC method. Its limits
+// can be updated while the server is running.
+type RateLimiter struct {
+	mu      sync.Mutex
+	cfg     config.RateLimitConfig
+	rates   map[string]int
+	buckets map[string]*tokenBucket
+}
+
+// NewRateLimiter creates a rate limiter from the server configuration
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	l := &RateLimiter{}
+	l.Update(cfg)
+	return l
+}
+
+// Update replaces the limits. Every bucket starts over full when the limits
+// change.
+func (l *RateLimiter) Update(cfg config.RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.buckets != nil && reflect.DeepEqual(cfg, l.cfg) {
+		return
+	}
+	l.cfg = cfg
+	// Viper lower-cases map keys, so overrides match method names in any case
+	l.rates = make(map[string]int, len(cfg.Methods))
+	for name, rps := range cfg.Methods {
+		l.rates[strings.ToLower(name)] = rps
+	}
+	l.buckets = make(map[string]*tokenBucket)
+}
+
+// Allow reports whether a call to the given full method name is within its
+// limit, taking a token if it is
+func (l *RateLimiter) Allow(method string) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.cfg.Enabled {
+		return true
+	}
+	b, ok := l.buckets[method]
+	if !ok {
+		rate := l.cfg.RequestsPerSecond
+		if rps, ok := l.rates[strings.ToLower(method[strings.LastIndexByte(method, '/')+1:])]; ok {
+			rate = rps
+		}
+		burst := float64(max(l.cfg.Burst, 1))
+		b = &tokenBucket{rate: float64(rate), burst: burst, tokens: burst, last: time.Now()}
+		l.buckets[method] = b
+	}
+	return b.take(time.Now())
+}
+
+// tokenBucket refills at rate tokens per second up to burst
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) take(now time.Time) bool {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
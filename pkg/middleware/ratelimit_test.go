@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+func TestTokenBucket(t *testing.T) {
+	type take struct {
+		after   time.Duration // since the previous take
+		allowed bool
+	}
+	tests := []struct {
+		name  string
+		takes []take
+	}{
+		{"burst then empty", []take{{0, true}, {0, true}, {0, true}, {0, false}}},
+		{"refills at rate", []take{
+			{0, true}, {0, true}, {0, true}, {0, false},
+			{500 * time.Millisecond, true}, {0, false},
+		}},
+		{"partial tokens add up", []take{
+			{0, true}, {0, true}, {0, true},
+			{250 * time.Millisecond, false}, {250 * time.Millisecond, true},
+		}},
+		{"refill capped at burst", []take{
+			{0, true}, {time.Minute, true}, {0, true}, {0, true}, {0, false},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 2 tokens per second, 3 at once
+			now := time.Now()
+			b := &tokenBucket{rate: 2, burst: 3, tokens: 3, last: now}
+			for i, step := range tt.takes {
+				now = now.Add(step.after)
+				assert.Equal(t, step.allowed, b.take(now), "take %d", i)
+			}
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	const (
+		getUser    = "/user.UserService/GetUser"
+		createUser = "/user.UserService/CreateUser"
+	)
+	enabled := config.RateLimitConfig{
+		Enabled:           true,
+		RequestsPerSecond: 1,
+		Burst:             2,
+		Methods:           map[string]int{"createuser": 5},
+	}
+
+	tests := []struct {
+		name  string
+		cfg   config.RateLimitConfig
+		calls []string
+		want  []bool
+	}{
+		{"disabled", config.RateLimitConfig{Burst: 1}, []string{getUser, getUser, getUser}, []bool{true, true, true}},
+		{"limits each method", enabled,
+			[]string{getUser, getUser, getUser, createUser, createUser, createUser},
+			[]bool{true, true, false, true, true, false}},
+		{"burst of at least one", config.RateLimitConfig{Enabled: true, RequestsPerSecond: 1}, []string{getUser, getUser}, []bool{true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(tt.cfg)
+			for i, method := range tt.calls {
+				assert.Equal(t, tt.want[i], l.Allow(method), "call %d to %s", i, method)
+			}
+		})
+	}
+
+	t.Run("nil allows", func(t *testing.T) {
+		var l *RateLimiter
+		assert.True(t, l.Allow(getUser))
+	})
+
+	t.Run("overrides ignore case", func(t *testing.T) {
+		l := NewRateLimiter(enabled)
+		l.Allow(getUser)
+		l.Allow(createUser)
+		assert.Equal(t, float64(1), l.buckets[getUser].rate)
+		assert.Equal(t, float64(5), l.buckets[createUser].rate)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		l := NewRateLimiter(enabled)
+		l.Allow(getUser)
+		l.Allow(getUser)
+		assert.False(t, l.Allow(getUser))
+
+		// The same limits keep the buckets
+		l.Update(enabled)
+		assert.False(t, l.Allow(getUser))
+
+		// New limits start every bucket over
+		changed := enabled
+		changed.Burst = 3
+		l.Update(changed)
+		assert.True(t, l.Allow(getUser))
+
+		changed.Enabled = false
+		l.Update(changed)
+		for i := 0; i < 5; i++ {
+			assert.True(t, l.Allow(getUser))
+		}
+	})
+}
@@ -0,0 +1,177 @@
+package shutdown
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
+)
+
+// DrainFunc stops accepting work and waits for in-flight work to finish. It
+// must give up and stop forcefully once ctx is done.
+type DrainFunc func(ctx context.Context) error
+
+type drainHook struct {
+	name string
+	fn   DrainFunc
+}
+
+type closeHook struct {
+	name string
+	fn   func() error
+}
+
+// Coordinator runs the shutdown of a server process in phases:
+//
+//  1. not serving hooks flip health checks so load balancers stop routing
+//  2. the pre-stop delay gives load balancers time to observe that
+//  3. drain hooks run concurrently, bounded by the drain timeout
+//  4. close hooks release resources in registration order
+type Coordinator struct {
+	preStopDelay time.Duration
+	drainTimeout time.Duration
+
+	mu         sync.Mutex
+	notServing []func()
+	drains     []drainHook
+	closers    []closeHook
+	once       sync.Once
+}
+
+// New creates a coordinator with the given pre-stop delay and drain deadline
+func New(preStopDelay, drainTimeout time.Duration) *Coordinator {
+	return &Coordinator{
+		preStopDelay: preStopDelay,
+		drainTimeout: drainTimeout,
+	}
+}
+
+// OnNotServing registers a hook that marks the process as not serving
+func (c *Coordinator) OnNotServing(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notServing = append(c.notServing, fn)
+}
+
+// OnDrain registers a server to drain
+func (c *Coordinator) OnDrain(name string, fn DrainFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.drains = append(c.drains, drainHook{name: name, fn: fn})
+}
+
+// OnClose registers a resource to release once draining has finished
+func (c *Coordinator) OnClose(name string, fn func() error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closers = append(c.closers, closeHook{name: name, fn: fn})
+}
+
+// Wait blocks until one of the signals is received and then shuts down.
+// A second signal during shutdown exits immediately.
+func (c *Coordinator) Wait(signals ...os.Signal) {
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, signals...)
+	sig := <-sigCh
+
+	logger.Info("Shutdown signal received", zap.String("signal", sig.String()))
+	go func() {
+		sig := <-sigCh
+		logger.Warn("Second shutdown signal received, exiting immediately", zap.String("signal", sig.String()))
+		logger.Sync()
+		os.Exit(1)
+	}()
+
+	c.Shutdown()
+}
+
+// Shutdown runs all phases. Only the first call has any effect.
+func (c *Coordinator) Shutdown() {
+	c.once.Do(c.shutdown)
+}
+
+func (c *Coordinator) shutdown() {
+	c.mu.Lock()
+	notServing := append([]func(){}, c.notServing...)
+	drains := append([]drainHook(nil), c.drains...)
+	closers := append([]closeHook(nil), c.closers...)
+	c.mu.Unlock()
+
+	for _, fn := range notServing {
+		fn()
+	}
+
+	if c.preStopDelay > 0 {
+		logger.Info("Waiting before draining", zap.Duration("pre_stop_delay", c.preStopDelay))
+		time.Sleep(c.preStopDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.drainTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, d := range drains {
+		wg.Add(1)
+		go func(d drainHook) {
+			defer wg.Done()
+
+			start := time.Now()
+			if err := d.fn(ctx); err != nil {
+				logger.Warn("Drain did not complete, stopped forcefully",
+					zap.String("component", d.name),
+					zap.Duration("duration", time.Since(start)),
+					zap.Error(err),
+				)
+				return
+			}
+			logger.Info("Drained", zap.String("component", d.name), zap.Duration("duration", time.Since(start)))
+		}(d)
+	}
+	wg.Wait()
+
+	for _, cl := range closers {
+		if err := cl.fn(); err != nil {
+			logger.Error("Error during shutdown", zap.String("component", cl.name), zap.Error(err))
+		}
+	}
+}
+
+// GRPCServer drains a gRPC server with GracefulStop, falling back to Stop
+// when the deadline passes
+func GRPCServer(s *grpc.Server) DrainFunc {
+	return func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			s.GracefulStop()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			s.Stop()
+			<-done
+			return ctx.Err()
+		}
+	}
+}
+
+// HTTPServer drains an HTTP server with Shutdown, closing the remaining
+// connections when the deadline passes
+func HTTPServer(s *http.Server) DrainFunc {
+	return func(ctx context.Context) error {
+		if err := s.Shutdown(ctx); err != nil {
+			s.Close()
+			return err
+		}
+		return nil
+	}
+}
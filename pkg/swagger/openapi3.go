@@ -0,0 +1,350 @@
+package swagger
+
+import (
+	"strings"
+)
+
+// openAPIVersion is the OpenAPI 3 version documents are converted to
+const openAPIVersion = "3.0.3"
+
+// problemContentType is the media type of the gateway's error responses
+const problemContentType = "application/problem+json"
+
+// parameterSchemaKeys are the Swagger 2.0 parameter fields that move into
+// the parameter schema in OpenAPI 3
+var parameterSchemaKeys = []string{
+	"type", "format", "items", "enum", "default", "pattern",
+	"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum",
+	"minLength", "maxLength", "minItems", "maxItems", "uniqueItems", "multipleOf",
+}
+
+// toOpenAPI3 converts a Swagger 2.0 document, as generated by
+// protoc-gen-openapiv2, to OpenAPI 3. Servers are left for the caller to
+// fill in since they depend on the request.
+func toOpenAPI3(sw map[string]interface{}) map[string]interface{} {
+	doc := map[string]interface{}{"openapi": openAPIVersion}
+	for k, v := range sw {
+		switch {
+		case k == "info" || k == "tags" || k == "externalDocs" || k == "security":
+			doc[k] = v
+		case strings.HasPrefix(k, "x-"):
+			doc[k] = v
+		}
+	}
+
+	consumes := stringList(sw["consumes"], "application/json")
+	produces := stringList(sw["produces"], "application/json")
+
+	// Body and form parameters become request bodies in OpenAPI 3, so
+	// references to shared ones are inlined; the rest stay shared
+	globalParams := asMap(sw["parameters"])
+
+	paths := make(map[string]interface{})
+	if swPaths, ok := sw["paths"].(map[string]interface{}); ok {
+		for path, item := range swPaths {
+			paths[path] = convertPathItem(asMap(item), globalParams, consumes, produces)
+		}
+	}
+	doc["paths"] = paths
+
+	components := make(map[string]interface{})
+	if defs, ok := sw["definitions"]; ok {
+		components["schemas"] = defs
+	}
+	params := make(map[string]interface{}, len(globalParams))
+	for name, p := range globalParams {
+		if param := asMap(p); param["in"] != "body" && param["in"] != "formData" {
+			params[name] = convertParameter(param)
+		}
+	}
+	if len(params) > 0 {
+		components["parameters"] = params
+	}
+	if swResponses, ok := sw["responses"].(map[string]interface{}); ok {
+		responses := make(map[string]interface{}, len(swResponses))
+		for name, r := range swResponses {
+			responses[name] = convertResponse(asMap(r), produces)
+		}
+		components["responses"] = responses
+	}
+	if schemes, ok := sw["securityDefinitions"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{}, len(schemes))
+		for name, scheme := range schemes {
+			converted[name] = convertSecurityScheme(asMap(scheme))
+		}
+		components["securitySchemes"] = converted
+	}
+	if len(components) > 0 {
+		doc["components"] = components
+	}
+
+	return rewriteRefs(doc).(map[string]interface{})
+}
+
+func convertPathItem(item, globalParams map[string]interface{}, consumes, produces []string) map[string]interface{} {
+	out := make(map[string]interface{}, len(item))
+	for key, value := range item {
+		switch key {
+		case "get", "put", "post", "delete", "options", "head", "patch":
+			out[key] = convertOperation(asMap(value), globalParams, consumes, produces)
+		case "parameters":
+			out[key] = convertParameters(value)
+		default:
+			out[key] = value
+		}
+	}
+	return out
+}
+
+func convertOperation(op, globalParams map[string]interface{}, consumes, produces []string) map[string]interface{} {
+	consumes = stringList(op["consumes"], consumes...)
+	produces = stringList(op["produces"], produces...)
+
+	out := make(map[string]interface{}, len(op))
+	for key, value := range op {
+		switch key {
+		case "consumes", "produces", "parameters", "responses":
+		default:
+			out[key] = value
+		}
+	}
+
+	var params []interface{}
+	formProps := make(map[string]interface{})
+	var formRequired []interface{}
+	for _, p := range asList(op["parameters"]) {
+		param := asMap(p)
+		if ref, ok := param["$ref"].(string); ok {
+			if shared := asMap(globalParams[strings.TrimPrefix(ref, "#/parameters/")]); shared["in"] == "body" || shared["in"] == "formData" {
+				param = shared
+			}
+		}
+		switch param["in"] {
+		case "body":
+			body := map[string]interface{}{"content": mediaTypes(consumes, param["schema"])}
+			if d, ok := param["description"]; ok {
+				body["description"] = d
+			}
+			if r, ok := param["required"]; ok {
+				body["required"] = r
+			}
+			out["requestBody"] = body
+		case "formData":
+			formProps[param["name"].(string)] = parameterSchema(param)
+			if param["required"] == true {
+				formRequired = append(formRequired, param["name"])
+			}
+		default:
+			params = append(params, convertParameter(param))
+		}
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+	if len(formProps) > 0 {
+		schema := map[string]interface{}{"type": "object", "properties": formProps}
+		if len(formRequired) > 0 {
+			schema["required"] = formRequired
+		}
+		out["requestBody"] = map[string]interface{}{
+			"content": map[string]interface{}{
+				"application/x-www-form-urlencoded": map[string]interface{}{"schema": schema},
+			},
+		}
+	}
+
+	responses := make(map[string]interface{})
+	for code, r := range asMap(op["responses"]) {
+		responses[code] = convertResponse(asMap(r), responseMediaTypes(code, produces))
+	}
+	out["responses"] = responses
+
+	return out
+}
+
+// convertResponse moves a response's schema under the given media types and
+// the type information of its headers into their schemas
+func convertResponse(resp map[string]interface{}, produces []string) map[string]interface{} {
+	if _, ok := resp["$ref"]; ok {
+		return resp
+	}
+
+	converted := map[string]interface{}{"description": resp["description"]}
+	if schema, ok := resp["schema"]; ok {
+		converted["content"] = mediaTypes(produces, schema)
+	}
+	if headers, ok := resp["headers"].(map[string]interface{}); ok {
+		convertedHeaders := make(map[string]interface{}, len(headers))
+		for name, h := range headers {
+			header := asMap(h)
+			ch := map[string]interface{}{"schema": parameterSchema(header)}
+			if d, ok := header["description"]; ok {
+				ch["description"] = d
+			}
+			convertedHeaders[name] = ch
+		}
+		converted["headers"] = convertedHeaders
+	}
+	return converted
+}
+
+// responseMediaTypes splits the produced media types between success and
+// error responses when errors are reported as problem documents
+func responseMediaTypes(code string, produces []string) []string {
+	var problem, other []string
+	for _, t := range produces {
+		if t == problemContentType {
+			problem = append(problem, t)
+		} else {
+			other = append(other, t)
+		}
+	}
+	if len(problem) == 0 || len(other) == 0 {
+		return produces
+	}
+	if code == "default" || strings.HasPrefix(code, "4") || strings.HasPrefix(code, "5") {
+		return problem
+	}
+	return other
+}
+
+func convertParameters(value interface{}) []interface{} {
+	var params []interface{}
+	for _, p := range asList(value) {
+		params = append(params, convertParameter(asMap(p)))
+	}
+	return params
+}
+
+// convertParameter moves the type information of a non-body parameter into
+// its schema and maps collectionFormat to style/explode
+func convertParameter(param map[string]interface{}) map[string]interface{} {
+	if _, ok := param["$ref"]; ok {
+		return param
+	}
+
+	out := map[string]interface{}{"schema": parameterSchema(param)}
+	for _, key := range []string{"name", "in", "description", "required", "allowEmptyValue"} {
+		if v, ok := param[key]; ok {
+			out[key] = v
+		}
+	}
+	for key, v := range param {
+		if strings.HasPrefix(key, "x-") {
+			out[key] = v
+		}
+	}
+
+	switch param["collectionFormat"] {
+	case "multi":
+		out["style"], out["explode"] = "form", true
+	case "csv":
+		out["style"], out["explode"] = "form", false
+	case "ssv":
+		out["style"] = "spaceDelimited"
+	case "pipes":
+		out["style"] = "pipeDelimited"
+	}
+	return out
+}
+
+func parameterSchema(param map[string]interface{}) map[string]interface{} {
+	schema := make(map[string]interface{})
+	for _, key := range parameterSchemaKeys {
+		if v, ok := param[key]; ok {
+			schema[key] = v
+		}
+	}
+	return schema
+}
+
+func convertSecurityScheme(scheme map[string]interface{}) map[string]interface{} {
+	switch scheme["type"] {
+	case "basic":
+		out := map[string]interface{}{"type": "http", "scheme": "basic"}
+		if d, ok := scheme["description"]; ok {
+			out["description"] = d
+		}
+		return out
+	case "oauth2":
+		flow := make(map[string]interface{})
+		for _, key := range []string{"authorizationUrl", "tokenUrl", "scopes"} {
+			if v, ok := scheme[key]; ok {
+				flow[key] = v
+			}
+		}
+		flows := map[string]interface{}{}
+		switch scheme["flow"] {
+		case "implicit":
+			flows["implicit"] = flow
+		case "password":
+			flows["password"] = flow
+		case "application":
+			flows["clientCredentials"] = flow
+		case "accessCode":
+			flows["authorizationCode"] = flow
+		}
+		return map[string]interface{}{"type": "oauth2", "flows": flows}
+	default:
+		// apiKey is unchanged
+		return scheme
+	}
+}
+
+// rewriteRefs points Swagger 2.0 references at their OpenAPI 3 locations
+func rewriteRefs(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			if ref, ok := item.(string); ok && k == "$ref" {
+				ref = strings.Replace(ref, "#/definitions/", "#/components/schemas/", 1)
+				ref = strings.Replace(ref, "#/parameters/", "#/components/parameters/", 1)
+				out[k] = strings.Replace(ref, "#/responses/", "#/components/responses/", 1)
+				continue
+			}
+			out[k] = rewriteRefs(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = rewriteRefs(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func mediaTypes(types []string, schema interface{}) map[string]interface{} {
+	content := make(map[string]interface{}, len(types))
+	for _, t := range types {
+		content[t] = map[string]interface{}{"schema": schema}
+	}
+	return content
+}
+
+func stringList(v interface{}, fallback ...string) []string {
+	list := asList(v)
+	if len(list) == 0 {
+		return fallback
+	}
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func asList(v interface{}) []interface{} {
+	l, _ := v.([]interface{})
+	return l
+}
@@ -0,0 +1,185 @@
+package swagger
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apispec "github.com/Akashdeep-Patra/go-grpc-sqlite/api/swagger"
+)
+
+func TestToOpenAPI3EmbeddedSpec(t *testing.T) {
+	var sw map[string]interface{}
+	require.NoError(t, json.Unmarshal(apispec.UserSpec, &sw))
+
+	doc := toOpenAPI3(sw)
+	assert.Equal(t, openAPIVersion, doc["openapi"])
+	assertRefsResolve(t, doc)
+
+	tests := []struct {
+		path       string
+		method     string
+		params     []string // name:in:type
+		requestRef string
+	}{
+		{"/v1/users", "get", []string{"pageSize:query:integer", "pageToken:query:string"}, ""},
+		{"/v1/users", "post", nil, "#/components/schemas/userCreateUserRequest"},
+		{"/v1/users/{id}", "get", []string{"id:path:string"}, ""},
+		{"/v1/users/{id}", "patch", []string{"id:path:string"}, "#/components/schemas/UserServiceUpdateUserBody"},
+		{"/v1/users/{id}", "delete", []string{"id:path:string"}, ""},
+		{"/v1/users:search", "get", []string{"query:query:string", "pageSize:query:integer", "pageToken:query:string"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			op := asMap(asMap(asMap(doc["paths"])[tt.path])[tt.method])
+			require.NotNil(t, op)
+
+			var params []string
+			for _, p := range asList(op["parameters"]) {
+				param := asMap(p)
+				assert.NotContains(t, param, "type", "type belongs in the schema")
+				params = append(params, param["name"].(string)+":"+param["in"].(string)+":"+asMap(param["schema"])["type"].(string))
+			}
+			assert.Equal(t, tt.params, params)
+
+			if tt.requestRef == "" {
+				assert.NotContains(t, op, "requestBody")
+			} else {
+				body := asMap(op["requestBody"])
+				schema := asMap(asMap(asMap(body["content"])["application/json"])["schema"])
+				assert.Equal(t, tt.requestRef, schema["$ref"])
+			}
+
+			responses := asMap(op["responses"])
+			assert.Contains(t, asMap(asMap(responses["200"])["content"]), "application/json")
+			assert.NotContains(t, asMap(asMap(responses["200"])["content"]), problemContentType)
+			assert.Equal(t, []string{problemContentType}, keys(asMap(asMap(responses["default"])["content"])))
+		})
+	}
+}
+
+func TestToOpenAPI3SharedDefinitions(t *testing.T) {
+	var sw map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"swagger": "2.0",
+		"info": {"title": "test", "version": "1.0"},
+		"paths": {
+			"/items": {
+				"get": {
+					"parameters": [
+						{"$ref": "#/parameters/limit"},
+						{"name": "tag", "in": "query", "type": "array", "items": {"type": "string"}, "collectionFormat": "multi"}
+					],
+					"responses": {"200": {"$ref": "#/responses/items"}}
+				},
+				"post": {
+					"parameters": [{"$ref": "#/parameters/item"}],
+					"responses": {"200": {"description": "created", "schema": {"$ref": "#/definitions/item"}}}
+				}
+			}
+		},
+		"parameters": {
+			"limit": {"name": "limit", "in": "query", "type": "integer", "maximum": 100},
+			"item": {"name": "item", "in": "body", "required": true, "schema": {"$ref": "#/definitions/item"}}
+		},
+		"responses": {
+			"items": {
+				"description": "items",
+				"schema": {"type": "array", "items": {"$ref": "#/definitions/item"}},
+				"headers": {"X-Total": {"type": "integer"}}
+			}
+		},
+		"definitions": {"item": {"type": "object"}},
+		"securityDefinitions": {"basic": {"type": "basic"}}
+	}`), &sw))
+
+	doc := toOpenAPI3(sw)
+	assertRefsResolve(t, doc)
+
+	components := asMap(doc["components"])
+	assert.Equal(t, map[string]interface{}{
+		"limit": map[string]interface{}{
+			"name": "limit", "in": "query",
+			"schema": map[string]interface{}{"type": "integer", "maximum": float64(100)},
+		},
+	}, components["parameters"])
+	assert.Equal(t, map[string]interface{}{"type": "http", "scheme": "basic"}, asMap(components["securitySchemes"])["basic"])
+
+	items := asMap(asMap(components["responses"])["items"])
+	assert.Equal(t, "#/components/schemas/item", asMap(asMap(asMap(asMap(asMap(items["content"])["application/json"])["schema"])["items"]))["$ref"])
+	assert.Equal(t, map[string]interface{}{"type": "integer"}, asMap(asMap(asMap(items["headers"])["X-Total"])["schema"]))
+
+	get := asMap(asMap(asMap(doc["paths"])["/items"])["get"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"$ref": "#/components/parameters/limit"},
+		map[string]interface{}{
+			"name": "tag", "in": "query", "style": "form", "explode": true,
+			"schema": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+	}, get["parameters"])
+	assert.Equal(t, "#/components/responses/items", asMap(asMap(get["responses"])["200"])["$ref"])
+
+	// A shared body parameter has no OpenAPI 3 parameter form, so it is inlined
+	post := asMap(asMap(asMap(doc["paths"])["/items"])["post"])
+	assert.NotContains(t, post, "parameters")
+	assert.Equal(t, map[string]interface{}{
+		"required": true,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{"$ref": "#/components/schemas/item"},
+			},
+		},
+	}, post["requestBody"])
+}
+
+// assertRefsResolve checks that every $ref in doc points at an existing
+// location in the document
+func assertRefsResolve(t *testing.T, doc map[string]interface{}) {
+	t.Helper()
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			for k, item := range value {
+				if ref, ok := item.(string); ok && k == "$ref" {
+					assert.True(t, resolves(doc, ref), "dangling reference %s", ref)
+					continue
+				}
+				walk(item)
+			}
+		case []interface{}:
+			for _, item := range value {
+				walk(item)
+			}
+		}
+	}
+	walk(doc)
+}
+
+func resolves(doc map[string]interface{}, ref string) bool {
+	if !strings.HasPrefix(ref, "#/components/") {
+		return false
+	}
+	var node interface{} = doc
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if node, ok = m[part]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func keys(m map[string]interface{}) []string {
+	var result []string
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
@@ -0,0 +1,184 @@
+package swagger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	apispec "github.com/Akashdeep-Patra/go-grpc-sqlite/api/swagger"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/gateway"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/version"
+)
+
+// Document formats
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+)
+
+// Spec serves the OpenAPI document embedded in the binary, both as the
+// generated Swagger 2.0 and converted to OpenAPI 3, in JSON and YAML. The
+// server URL is filled in from each request.
+type Spec struct {
+	basePath   string
+	proxies    *gateway.ClientIPResolver
+	apiVersion string
+	swagger    map[string]interface{}
+	openapi    map[string]interface{}
+}
+
+// NewSpec parses the embedded document. basePath is the prefix the API is
+// served under, e.g. when the gateway sits behind a path-based proxy. The
+// scheme and host reported in X-Forwarded-Proto and X-Forwarded-Host are
+// only used for requests from trustedProxies.
+func NewSpec(basePath string, trustedProxies []string) (*Spec, error) {
+	proxies, err := gateway.NewClientIPResolver(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(apispec.UserSpec, &doc); err != nil {
+		return nil, fmt.Errorf("parse embedded OpenAPI spec: %w", err)
+	}
+
+	basePath = "/" + strings.Trim(basePath, "/")
+	if basePath != "/" {
+		doc["basePath"] = basePath
+	}
+
+	info, _ := doc["info"].(map[string]interface{})
+	if info == nil {
+		info = make(map[string]interface{})
+		doc["info"] = info
+	}
+	info["x-build-version"] = version.Version
+	info["x-build-commit"] = version.Commit
+
+	// The URL version is the major part of the API version, e.g. 1.0 -> v1
+	apiVersion, _ := info["version"].(string)
+	if major, _, _ := strings.Cut(apiVersion, "."); major != "" {
+		apiVersion = "v" + strings.TrimPrefix(major, "v")
+	} else {
+		apiVersion = "v1"
+	}
+
+	return &Spec{
+		basePath:   basePath,
+		proxies:    proxies,
+		apiVersion: apiVersion,
+		swagger:    doc,
+		openapi:    toOpenAPI3(doc),
+	}, nil
+}
+
+// Register serves the Swagger UI and the documents on mux:
+//
+//	/swagger/                         Swagger UI
+//	/swagger.json                     Swagger 2.0, loaded by the UI
+//	/openapi/{version}/swagger.json   Swagger 2.0 (also .yaml)
+//	/openapi/{version}/openapi.json   OpenAPI 3 (also .yaml)
+func (s *Spec) Register(mux *http.ServeMux) {
+	mux.Handle("/swagger/", UIHandler())
+	mux.Handle("/swagger.json", s.handler(s.swaggerFor, formatJSON))
+
+	prefix := "/openapi/" + s.apiVersion + "/"
+	for _, format := range []string{formatJSON, formatYAML} {
+		mux.Handle(prefix+"swagger."+format, s.handler(s.swaggerFor, format))
+		mux.Handle(prefix+"openapi."+format, s.handler(s.openAPIFor, format))
+	}
+}
+
+// swaggerFor returns the Swagger 2.0 document with the request's host and scheme
+func (s *Spec) swaggerFor(r *http.Request) map[string]interface{} {
+	scheme, host := s.requestOrigin(r)
+	doc := shallowCopy(s.swagger)
+	doc["host"] = host
+	doc["schemes"] = []string{scheme}
+	return doc
+}
+
+// openAPIFor returns the OpenAPI 3 document with the request's server URL
+func (s *Spec) openAPIFor(r *http.Request) map[string]interface{} {
+	scheme, host := s.requestOrigin(r)
+	doc := shallowCopy(s.openapi)
+	doc["servers"] = []map[string]string{
+		{"url": strings.TrimSuffix(scheme+"://"+path.Join(host, s.basePath), "/")},
+	}
+	return doc
+}
+
+func (s *Spec) handler(build func(r *http.Request) map[string]interface{}, format string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			body []byte
+			err  error
+		)
+		doc := build(r)
+		if format == formatYAML {
+			w.Header().Set("Content-Type", "application/yaml")
+			body, err = marshalYAML(doc)
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+			body, err = json.MarshalIndent(doc, "", "  ")
+		}
+		if err != nil {
+			http.Error(w, "Failed to render OpenAPI spec", http.StatusInternalServerError)
+			logger.Error("Failed to render OpenAPI spec", zap.Error(err))
+			return
+		}
+
+		if _, err := w.Write(body); err != nil {
+			logger.Error("Failed to write response", zap.Error(err))
+		}
+	})
+}
+
+func marshalYAML(doc map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// requestOrigin returns the scheme and host the client used, as reported by
+// a trusted reverse proxy when present
+func (s *Spec) requestOrigin(r *http.Request) (string, string) {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+	if !s.proxies.FromTrustedProxy(r) {
+		return scheme, host
+	}
+
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme, _, _ = strings.Cut(proto, ",")
+	}
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host, _, _ = strings.Cut(forwarded, ",")
+	}
+	return strings.TrimSpace(scheme), strings.TrimSpace(host)
+}
+
+func shallowCopy(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m)+2)
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,43 @@
+package swagger
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecServerURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"direct", "203.0.113.7:40000", nil, "http://api.example.com/api"},
+		{"untrusted forwarding headers", "203.0.113.7:40000", map[string]string{
+			"X-Forwarded-Proto": "https",
+			"X-Forwarded-Host":  "evil.example.com",
+		}, "http://api.example.com/api"},
+		{"trusted proxy", "10.0.0.2:40000", map[string]string{
+			"X-Forwarded-Proto": "https, http",
+			"X-Forwarded-Host":  "public.example.com, api.example.com",
+		}, "https://public.example.com/api"},
+	}
+	spec, err := NewSpec("/api/", []string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://api.example.com/openapi/v1/openapi.json", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			doc := spec.openAPIFor(r)
+			assert.Equal(t, []map[string]string{{"url": tt.want}}, doc["servers"])
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package swagger
+
+import (
+	"embed"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
+)
+
+//go:embed swagger-ui
+var swaggerUI embed.FS
+
+// UIHandler serves the Swagger UI files under the /swagger/ prefix
+func UIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Strip the /swagger/ prefix
+		uiPath := strings.TrimPrefix(r.URL.Path, "/swagger/")
+		if uiPath == "" {
+			uiPath = "index.html"
+		}
+
+		// Set content type based on file extension
+		ext := path.Ext(uiPath)
+		if ext != "" {
+			contentType := mime.TypeByExtension(ext)
+			if contentType != "" {
+				w.Header().Set("Content-Type", contentType)
+			}
+		}
+
+		// Open and serve the file from the embedded filesystem
+		content, err := fs.ReadFile(swaggerUI, "swagger-ui/"+uiPath)
+		if err != nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		_, err = w.Write(content)
+		if err != nil {
+			logger.Error("Failed to write response", zap.Error(err))
+		}
+	})
+}
@@ -0,0 +1,57 @@
+// Package tlscert serves the server's TLS certificate. The certificate can
+// be replaced while the server is running, so renewals apply to new
+// connections without a restart.
+package tlscert
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+// Certificate holds the key pair presented in TLS handshakes
+type Certificate struct {
+	pair atomic.Pointer[tls.Certificate]
+}
+
+// New creates a certificate from the server's TLS configuration
+func New(cfg config.TLSConfig) (*Certificate, error) {
+	c := &Certificate{}
+	if err := c.Update(cfg); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Update replaces the key pair. The previous one is kept when cfg does not
+// hold a valid key pair.
+func (c *Certificate) Update(cfg config.TLSConfig) error {
+	pair, err := tls.X509KeyPair([]byte(cfg.Cert.Value()), []byte(cfg.Key.Value()))
+	if err != nil {
+		return fmt.Errorf("invalid TLS certificate or key: %w", err)
+	}
+	c.pair.Store(&pair)
+	return nil
+}
+
+// NotAfter returns when the current certificate expires
+func (c *Certificate) NotAfter() time.Time {
+	if leaf := c.pair.Load().Leaf; leaf != nil {
+		return leaf.NotAfter
+	}
+	return time.Time{}
+}
+
+// ServerConfig returns a TLS configuration that presents the current key
+// pair in every handshake
+func (c *Certificate) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return c.pair.Load(), nil
+		},
+	}
+}
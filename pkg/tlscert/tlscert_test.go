@@ -0,0 +1,83 @@
+package tlscert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+// selfSigned returns the configuration of a certificate for localhost that
+// expires at notAfter
+func selfSigned(t *testing.T, notAfter time.Time) config.TLSConfig {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(notAfter.Unix()),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return config.TLSConfig{
+		Enabled: true,
+		Cert:    config.NewSecret(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))),
+		Key:     config.NewSecret(string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))),
+	}
+}
+
+func TestCertificate(t *testing.T) {
+	first := selfSigned(t, time.Now().Add(24*time.Hour).Truncate(time.Second))
+	renewed := selfSigned(t, time.Now().Add(48*time.Hour).Truncate(time.Second))
+
+	_, err := New(config.TLSConfig{Enabled: true})
+	assert.Error(t, err, "no key pair")
+
+	c, err := New(first)
+	require.NoError(t, err)
+	serverConfig := c.ServerConfig()
+	assert.Equal(t, uint16(tls.VersionTLS12), serverConfig.MinVersion)
+
+	served := func() time.Time {
+		t.Helper()
+		pair, err := serverConfig.GetCertificate(&tls.ClientHelloInfo{ServerName: "localhost"})
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(pair.Certificate[0])
+		require.NoError(t, err)
+		return leaf.NotAfter
+	}
+	notAfter := func(cfg config.TLSConfig) time.Time {
+		block, _ := pem.Decode([]byte(cfg.Cert.Value()))
+		leaf, err := x509.ParseCertificate(block.Bytes)
+		require.NoError(t, err)
+		return leaf.NotAfter
+	}
+	assert.Equal(t, notAfter(first), served())
+	assert.Equal(t, notAfter(first), c.NotAfter())
+
+	// A renewed pair is served by the configuration handed out before
+	require.NoError(t, c.Update(renewed))
+	assert.Equal(t, notAfter(renewed), served())
+	assert.Equal(t, notAfter(renewed), c.NotAfter())
+
+	// A certificate that does not match its key keeps the current pair
+	mismatched := config.TLSConfig{Enabled: true, Cert: first.Cert, Key: renewed.Key}
+	assert.Error(t, c.Update(mismatched))
+	assert.Equal(t, notAfter(renewed), served())
+}
@@ -0,0 +1,174 @@
+package upstream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/logger"
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/metrics"
+)
+
+// Circuit states
+const (
+	stateClosed = iota
+	stateOpen
+	stateHalfOpen
+)
+
+// ErrCircuitOpen is returned without calling the server while the circuit
+// is open. The gateway reports it as 503 Service Unavailable.
+var ErrCircuitOpen = status.Error(codes.Unavailable, "upstream unavailable: circuit open")
+
+// Breaker is a circuit breaker for upstream calls. After threshold
+// consecutive failures it rejects calls for openTimeout, then lets a single
+// trial call through: success closes the circuit, failure opens it again.
+type Breaker struct {
+	threshold   int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	state    int
+	failures int
+	openedAt time.Time
+	trial    bool
+	// generation counts state changes, so that a call finishing after the
+	// state it was let through in has changed is ignored
+	generation uint64
+}
+
+// ticket is handed to a call let through by allow and returned to record
+// with its outcome
+type ticket struct {
+	generation uint64
+	trial      bool
+}
+
+// NewBreaker creates a closed circuit breaker
+func NewBreaker(threshold int, openTimeout time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Breaker{threshold: threshold, openTimeout: openTimeout}
+}
+
+// UnaryClientInterceptor rejects calls while the circuit is open and
+// records the outcome of the calls it lets through
+func (b *Breaker) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		t, ok := b.allow()
+		if !ok {
+			return ErrCircuitOpen
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(ctx, t, err)
+		return err
+	}
+}
+
+// allow reports whether a call may go through and, if so, returns the
+// ticket to record its outcome with
+func (b *Breaker) allow() (ticket, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return ticket{}, false
+		}
+		b.setState(stateHalfOpen)
+		b.trial = true
+		return ticket{generation: b.generation, trial: true}, true
+	case stateHalfOpen:
+		// Only the trial call is let through
+		if b.trial {
+			return ticket{}, false
+		}
+		b.trial = true
+		return ticket{generation: b.generation, trial: true}, true
+	default:
+		return ticket{generation: b.generation}, true
+	}
+}
+
+// record applies the outcome of a call. Only the trial call settles a
+// half-open circuit; a call let through before the state last changed says
+// nothing about the current state and is ignored.
+func (b *Breaker) record(ctx context.Context, t ticket, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if t.generation != b.generation {
+		return
+	}
+	if t.trial {
+		b.trial = false
+	}
+	switch outcomeOf(ctx, err) {
+	case outcomeSuccess:
+		b.failures = 0
+		if b.state != stateClosed {
+			b.setState(stateClosed)
+		}
+	case outcomeFailure:
+		b.failures++
+		if b.state == stateHalfOpen || b.failures >= b.threshold {
+			b.openedAt = time.Now()
+			if b.state != stateOpen {
+				b.setState(stateOpen)
+			}
+		}
+	}
+}
+
+func (b *Breaker) setState(state int) {
+	b.state = state
+	b.generation++
+	log := logger.Named("upstream")
+	switch state {
+	case stateOpen:
+		metrics.UpstreamCircuitOpen.Set(1)
+		log.Warn("Upstream circuit opened",
+			zap.Int("failures", b.failures),
+			zap.Duration("open_timeout", b.openTimeout),
+		)
+	case stateHalfOpen:
+		log.Info("Upstream circuit half-open, trying a call")
+	case stateClosed:
+		metrics.UpstreamCircuitOpen.Set(0)
+		log.Info("Upstream circuit closed")
+	}
+}
+
+// Call outcomes as seen by the breaker
+const (
+	outcomeSuccess = iota
+	outcomeFailure
+	// outcomeNeutral says nothing about the server's health
+	outcomeNeutral
+)
+
+// outcomeOf classifies a call. Errors showing the server is unavailable or
+// overloaded are failures; application errors show it is up and count as
+// successes. A caller giving up is neutral, so it neither resets the
+// failure count nor settles a half-open trial.
+func outcomeOf(ctx context.Context, err error) int {
+	if err == nil {
+		return outcomeSuccess
+	}
+	if ctx.Err() == context.Canceled {
+		return outcomeNeutral
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return outcomeFailure
+	default:
+		return outcomeSuccess
+	}
+}
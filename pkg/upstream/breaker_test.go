@@ -0,0 +1,186 @@
+package upstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// breakerStep is one call through the breaker: whether it is let through,
+// the result it gets, and the state afterwards
+type breakerStep struct {
+	// elapse moves the open timeout into the past before the call
+	elapse  bool
+	allowed bool
+	err     error
+	// canceled marks calls whose caller gave up
+	canceled bool
+	state    int
+}
+
+var (
+	errUnavailable = status.Error(codes.Unavailable, "connection refused")
+	errNotFound    = status.Error(codes.NotFound, "user not found")
+)
+
+func TestBreaker(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []breakerStep
+	}{
+		{"stays closed below threshold", []breakerStep{
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateClosed},
+		}},
+		{"application errors are successes", []breakerStep{
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, err: errNotFound, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateClosed},
+		}},
+		{"opens at threshold and rejects", []breakerStep{
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateOpen},
+			{allowed: false, state: stateOpen},
+		}},
+		{"successful trial closes", []breakerStep{
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateOpen},
+			{elapse: true, allowed: true, state: stateClosed},
+			{allowed: true, state: stateClosed},
+		}},
+		{"failed trial reopens", []breakerStep{
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateOpen},
+			{elapse: true, allowed: true, err: errUnavailable, state: stateOpen},
+			{allowed: false, state: stateOpen},
+		}},
+		{"canceled trial leaves circuit half-open", []breakerStep{
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateOpen},
+			{elapse: true, allowed: true, err: status.Error(codes.Canceled, "context canceled"), canceled: true, state: stateHalfOpen},
+			{allowed: true, err: errUnavailable, state: stateOpen},
+		}},
+		{"canceled call keeps failure count", []breakerStep{
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateClosed},
+			{allowed: true, err: status.Error(codes.Canceled, "context canceled"), canceled: true, state: stateClosed},
+			{allowed: true, err: errUnavailable, state: stateOpen},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBreaker(3, time.Minute)
+			for i, step := range tt.steps {
+				if step.elapse {
+					b.openedAt = b.openedAt.Add(-time.Minute)
+				}
+				ticket, allowed := b.allow()
+				assert.Equal(t, step.allowed, allowed, "step %d allowed", i)
+				if allowed {
+					ctx, cancel := context.WithCancel(context.Background())
+					if step.canceled {
+						cancel()
+					}
+					b.record(ctx, ticket, step.err)
+					cancel()
+				}
+				assert.Equal(t, step.state, b.state, "step %d state", i)
+			}
+		})
+	}
+}
+
+func TestBreakerSingleTrial(t *testing.T) {
+	ctx := context.Background()
+	// open fails a call so the threshold of one opens the circuit, then lets
+	// the open timeout pass
+	open := func(t *testing.T, b *Breaker) {
+		t.Helper()
+		failing, ok := b.allow()
+		assert.True(t, ok)
+		b.record(ctx, failing, errUnavailable)
+		assert.Equal(t, stateOpen, b.state)
+	}
+	elapse := func(b *Breaker) { b.openedAt = b.openedAt.Add(-time.Minute) }
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, b *Breaker)
+	}{
+		{"second call during the trial", func(t *testing.T, b *Breaker) {
+			open(t, b)
+			elapse(b)
+			_, ok := b.allow()
+			assert.True(t, ok, "trial call")
+			_, ok = b.allow()
+			assert.False(t, ok, "second call during the trial")
+		}},
+		{"stale call finishing while open", func(t *testing.T, b *Breaker) {
+			stale, _ := b.allow()
+			open(t, b)
+			b.record(ctx, stale, nil)
+			assert.Equal(t, stateOpen, b.state)
+			_, ok := b.allow()
+			assert.False(t, ok)
+		}},
+		{"stale success during the trial", func(t *testing.T, b *Breaker) {
+			stale, _ := b.allow()
+			open(t, b)
+			elapse(b)
+			trial, ok := b.allow()
+			assert.True(t, ok, "trial call")
+
+			b.record(ctx, stale, nil)
+			assert.Equal(t, stateHalfOpen, b.state, "stale call closed the circuit")
+			_, ok = b.allow()
+			assert.False(t, ok, "stale call let a second trial through")
+
+			b.record(ctx, trial, errUnavailable)
+			assert.Equal(t, stateOpen, b.state)
+		}},
+		{"stale failure during the trial", func(t *testing.T, b *Breaker) {
+			stale, _ := b.allow()
+			open(t, b)
+			elapse(b)
+			trial, _ := b.allow()
+
+			b.record(ctx, stale, errUnavailable)
+			assert.Equal(t, stateHalfOpen, b.state, "stale call reopened the circuit")
+
+			b.record(ctx, trial, nil)
+			assert.Equal(t, stateClosed, b.state)
+		}},
+		{"stale call after the circuit reopened", func(t *testing.T, b *Breaker) {
+			stale, _ := b.allow()
+			open(t, b)
+			elapse(b)
+			trial, _ := b.allow()
+			b.record(ctx, trial, errUnavailable)
+			openedAt := b.openedAt
+
+			b.record(ctx, stale, errUnavailable)
+			assert.Equal(t, stateOpen, b.state)
+			assert.Equal(t, openedAt, b.openedAt, "stale call extended the open timeout")
+			elapse(b)
+			_, ok := b.allow()
+			assert.True(t, ok, "next trial call")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, NewBreaker(1, time.Minute))
+		})
+	}
+}
@@ -0,0 +1,233 @@
+// Package upstream dials the gRPC server from the gateway with load
+// balancing, client-side health checking, retries, per-method deadlines and
+// a circuit breaker.
+package upstream
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	// Registers the client-side health checking function
+	_ "google.golang.org/grpc/health"
+
+	"github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/config"
+)
+
+// Dial creates a client connection to target for the given service. Use a
+// dns:/// target to balance across every address the name resolves to.
+func Dial(target string, cfg config.UpstreamConfig, service grpc.ServiceDesc) (*grpc.ClientConn, error) {
+	serviceConfig, err := ServiceConfig(cfg, service)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := insecure.NewCredentials()
+	if cfg.TLS.Enabled {
+		tlsConfig, err := clientTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+	}
+	if cfg.CircuitBreaker.Enabled {
+		breaker := NewBreaker(cfg.CircuitBreaker.FailureThreshold, time.Duration(cfg.CircuitBreaker.OpenTimeout)*time.Second)
+		opts = append(opts, grpc.WithChainUnaryInterceptor(breaker.UnaryClientInterceptor()))
+	}
+	return grpc.NewClient(target, opts...)
+}
+
+// clientTLSConfig builds the TLS configuration verifying the server
+func clientTLSConfig(cfg config.UpstreamTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: cfg.ServerName}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read upstream CA file: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+	}
+	return tlsConfig, nil
+}
+
+// serviceConfig is the subset of the gRPC service config the gateway sets,
+// see https://github.com/grpc/grpc/blob/master/doc/service_config.md
+type serviceConfig struct {
+	LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig"`
+	HealthCheckConfig   *healthCheckConfig    `json:"healthCheckConfig,omitempty"`
+	MethodConfig        []methodConfig        `json:"methodConfig,omitempty"`
+}
+
+type healthCheckConfig struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type methodConfig struct {
+	Name        []methodName `json:"name"`
+	Timeout     string       `json:"timeout,omitempty"`
+	RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method,omitempty"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+// ServiceConfig renders the gRPC service config JSON for service: the load
+// balancing policy, health checking, a service-wide deadline with per-method
+// overrides and the retry policy of the retryable methods
+func ServiceConfig(cfg config.UpstreamConfig, service grpc.ServiceDesc) (string, error) {
+	policy := strings.ToLower(cfg.LoadBalancing)
+	switch policy {
+	case "pick_first", "round_robin":
+	default:
+		return "", fmt.Errorf("unsupported load balancing policy %q", cfg.LoadBalancing)
+	}
+
+	sc := serviceConfig{
+		LoadBalancingConfig: []map[string]struct{}{{policy: {}}},
+	}
+	if cfg.HealthCheck {
+		sc.HealthCheckConfig = &healthCheckConfig{ServiceName: cfg.HealthCheckService}
+	}
+
+	// Method names are matched case-insensitively since configuration keys
+	// are lower-cased when loaded
+	methods := make(map[string]string, len(service.Methods)+len(service.Streams))
+	for _, m := range service.Methods {
+		methods[strings.ToLower(m.MethodName)] = m.MethodName
+	}
+	for _, m := range service.Streams {
+		methods[strings.ToLower(m.StreamName)] = m.StreamName
+	}
+	lookup := func(name string) (string, error) {
+		method, ok := methods[strings.ToLower(name)]
+		if !ok {
+			return "", fmt.Errorf("unknown method %q of %s", name, service.ServiceName)
+		}
+		return method, nil
+	}
+
+	overrides := make(map[string]*methodConfig)
+	override := func(method string) *methodConfig {
+		mc, ok := overrides[method]
+		if !ok {
+			mc = &methodConfig{Name: []methodName{{Service: service.ServiceName, Method: method}}}
+			if cfg.Timeout > 0 {
+				mc.Timeout = seconds(cfg.Timeout)
+			}
+			overrides[method] = mc
+		}
+		return mc
+	}
+
+	for name, timeout := range cfg.Timeouts {
+		method, err := lookup(name)
+		if err != nil {
+			return "", fmt.Errorf("invalid upstream timeout: %w", err)
+		}
+		mc := override(method)
+		mc.Timeout = ""
+		if timeout > 0 {
+			mc.Timeout = seconds(timeout)
+		}
+	}
+
+	if cfg.Retry.MaxAttempts > 1 && len(cfg.Retry.Methods) > 0 {
+		policy, err := newRetryPolicy(cfg.Retry)
+		if err != nil {
+			return "", err
+		}
+		for _, name := range cfg.Retry.Methods {
+			method, err := lookup(name)
+			if err != nil {
+				return "", fmt.Errorf("invalid retry method: %w", err)
+			}
+			override(method).RetryPolicy = policy
+		}
+	}
+
+	// The service-wide entry applies to methods without their own
+	if cfg.Timeout > 0 {
+		sc.MethodConfig = append(sc.MethodConfig, methodConfig{
+			Name:    []methodName{{Service: service.ServiceName}},
+			Timeout: seconds(cfg.Timeout),
+		})
+	}
+	for _, m := range service.Methods {
+		if mc, ok := overrides[m.MethodName]; ok {
+			sc.MethodConfig = append(sc.MethodConfig, *mc)
+		}
+	}
+	for _, m := range service.Streams {
+		if mc, ok := overrides[m.StreamName]; ok {
+			sc.MethodConfig = append(sc.MethodConfig, *mc)
+		}
+	}
+
+	b, err := json.Marshal(sc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func newRetryPolicy(cfg config.RetryConfig) (*retryPolicy, error) {
+	if cfg.InitialBackoffMs <= 0 || cfg.MaxBackoffMs < cfg.InitialBackoffMs {
+		return nil, fmt.Errorf("invalid retry backoff: initial %dms, max %dms", cfg.InitialBackoffMs, cfg.MaxBackoffMs)
+	}
+
+	var retryable []string
+	for _, name := range cfg.RetryableCodes {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		var code codes.Code
+		// UnmarshalJSON accepts the quoted upper-case code names
+		if err := code.UnmarshalJSON([]byte(`"` + name + `"`)); err != nil {
+			return nil, fmt.Errorf("invalid retryable code %q", name)
+		}
+		retryable = append(retryable, name)
+	}
+	if len(retryable) == 0 {
+		return nil, fmt.Errorf("retry policy needs at least one retryable code")
+	}
+
+	return &retryPolicy{
+		MaxAttempts:          cfg.MaxAttempts,
+		InitialBackoff:       milliseconds(cfg.InitialBackoffMs),
+		MaxBackoff:           milliseconds(cfg.MaxBackoffMs),
+		BackoffMultiplier:    2,
+		RetryableStatusCodes: retryable,
+	}, nil
+}
+
+// Durations in the service config are decimal seconds with an "s" suffix
+func seconds(n int) string {
+	return fmt.Sprintf("%ds", n)
+}
+
+func milliseconds(n int) string {
+	return fmt.Sprintf("%.3fs", float64(n)/1000)
+}
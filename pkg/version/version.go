@@ -0,0 +1,10 @@
+package version
+
+// Build information, overridden at link time with
+// -ldflags "-X github.com/Akashdeep-Patra/go-grpc-sqlite/pkg/version.Version=v1.2.3"
+var (
+	// Version is the semantic version of the build
+	Version = "dev"
+	// Commit is the git commit the build was produced from
+	Commit = "unknown"
+)
@@ -3,7 +3,7 @@
 set -e
 
 SWAGGER_UI_VERSION="4.18.3"
-SWAGGER_UI_DIR="cmd/gateway/swagger-ui"
+SWAGGER_UI_DIR="pkg/swagger/swagger-ui"
 
 # Create directory if it doesn't exist
 mkdir -p $SWAGGER_UI_DIR
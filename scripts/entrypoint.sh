@@ -1,5 +1,10 @@
 #!/bin/sh
 
+# In single-port mode the server also serves the REST gateway
+if [ "$APP_SERVER_SINGLE_PORT" = "true" ]; then
+    exec /app/server
+fi
+
 # Start the gRPC server in the background
 /app/server &
 SERVER_PID=$!
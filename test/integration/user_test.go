@@ -4,13 +4,16 @@ package integration
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/Akashdeep-Patra/go-grpc-sqlite/gen/go/github.com/Akashdeep-Patra/go-grpc-sqlite/user"
 )
@@ -49,4 +52,79 @@ func TestCreateAndGetUser(t *testing.T) {
 	assert.Equal(t, createResp.Id, getResp.Id, "User ID should match")
 	assert.Equal(t, createResp.Name, getResp.Name, "User name should match")
 	assert.Equal(t, createResp.Email, getResp.Email, "User email should match")
-} 
\ No newline at end of file
+}
+
+func TestUserLifecycle(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, serverAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err, "Failed to connect to server")
+	defer conn.Close()
+
+	client := pb.NewUserServiceClient(conn)
+
+	// Watch for the changes made below
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	watch, err := client.WatchUsers(watchCtx, &pb.WatchUsersRequest{})
+	require.NoError(t, err, "Failed to watch users")
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	created, err := client.CreateUser(ctx, &pb.CreateUserRequest{
+		Name:  "Lifecycle " + suffix,
+		Email: "lifecycle-" + suffix + "@example.com",
+	})
+	require.NoError(t, err, "Failed to create user")
+
+	// A second user with the same email is rejected
+	_, err = client.CreateUser(ctx, &pb.CreateUserRequest{Name: "Duplicate", Email: created.Email})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err), "Duplicate email should be rejected")
+
+	// Update only the name
+	updated, err := client.UpdateUser(ctx, &pb.UpdateUserRequest{Id: created.Id, Name: "Renamed " + suffix})
+	require.NoError(t, err, "Failed to update user")
+	assert.Equal(t, "Renamed "+suffix, updated.Name, "User name should be updated")
+	assert.Equal(t, created.Email, updated.Email, "User email should be unchanged")
+
+	// Search finds the user by email
+	found, err := client.SearchUsers(ctx, &pb.SearchUsersRequest{Query: suffix})
+	require.NoError(t, err, "Failed to search users")
+	require.Len(t, found.Users, 1, "Search should find the user")
+	assert.Equal(t, created.Id, found.Users[0].Id, "Search should return the user")
+
+	// Listing pages through every user
+	var listed []*pb.UserResponse
+	token := ""
+	for {
+		page, err := client.ListUsers(ctx, &pb.ListUsersRequest{PageSize: 2, PageToken: token})
+		require.NoError(t, err, "Failed to list users")
+		listed = append(listed, page.Users...)
+		if token = page.NextPageToken; token == "" {
+			break
+		}
+	}
+	ids := make([]string, len(listed))
+	for i, u := range listed {
+		ids[i] = u.Id
+	}
+	assert.Contains(t, ids, created.Id, "List should include the user")
+
+	// Delete the user
+	_, err = client.DeleteUser(ctx, &pb.DeleteUserRequest{Id: created.Id})
+	require.NoError(t, err, "Failed to delete user")
+	_, err = client.GetUser(ctx, &pb.GetUserRequest{Id: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err), "Deleted user should not be found")
+
+	// The watch saw every change in order
+	for _, want := range []pb.UserEvent_Type{pb.UserEvent_CREATED, pb.UserEvent_UPDATED, pb.UserEvent_DELETED} {
+		for {
+			event, err := watch.Recv()
+			require.NoError(t, err, "Failed to receive event")
+			if event.User.Id == created.Id {
+				assert.Equal(t, want, event.Type, "Event type should match")
+				break
+			}
+		}
+	}
+}